@@ -0,0 +1,186 @@
+// Package cobertura defines a Cobertura XML coverage report and includes
+// convenience methods for creating these reports.
+package cobertura
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/jstemmer/go-junit-report/v2/gtr"
+)
+
+// DocType is the document type declaration of Cobertura XML reports.
+const DocType = `<!DOCTYPE coverage SYSTEM "http://cobertura.sourceforge.net/xml/coverage-04.dtd">`
+
+// Coverage is the root element of a Cobertura XML report.
+type Coverage struct {
+	XMLName xml.Name `xml:"coverage"`
+
+	LineRate        string `xml:"line-rate,attr"`
+	BranchRate      string `xml:"branch-rate,attr"`
+	LinesCovered    int    `xml:"lines-covered,attr"`
+	LinesValid      int    `xml:"lines-valid,attr"`
+	BranchesCovered int    `xml:"branches-covered,attr"`
+	BranchesValid   int    `xml:"branches-valid,attr"`
+	Complexity      string `xml:"complexity,attr"`
+	Version         string `xml:"version,attr"`
+	Timestamp       int64  `xml:"timestamp,attr"` // milliseconds since the Unix epoch
+
+	Sources  []string  `xml:"sources>source"`
+	Packages []Package `xml:"packages>package"`
+}
+
+// Package contains the coverage of the files in a single package.
+type Package struct {
+	Name       string `xml:"name,attr"`
+	LineRate   string `xml:"line-rate,attr"`
+	BranchRate string `xml:"branch-rate,attr"`
+	Complexity string `xml:"complexity,attr"`
+
+	Classes []Class `xml:"classes>class"`
+
+	linesCovered, linesValid int
+}
+
+// Class contains the coverage of a single source file.
+type Class struct {
+	Name       string `xml:"name,attr"`
+	Filename   string `xml:"filename,attr"`
+	LineRate   string `xml:"line-rate,attr"`
+	BranchRate string `xml:"branch-rate,attr"`
+	Complexity string `xml:"complexity,attr"`
+
+	Methods struct{} `xml:"methods"`
+	Lines   []Line   `xml:"lines>line"`
+}
+
+// Line contains the number of times a single line was executed.
+type Line struct {
+	Number int   `xml:"number,attr"`
+	Hits   int64 `xml:"hits,attr"`
+}
+
+// WriteXML writes the XML representation of Coverage c to writer w, including
+// the XML header and document type declaration.
+func (c *Coverage) WriteXML(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "%s%s\n", xml.Header, DocType); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "\t")
+	if err := enc.Encode(c); err != nil {
+		return err
+	}
+	if err := enc.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\n")
+	return err
+}
+
+// Option allows you to configure how Cobertura reports are created.
+type Option func(*options)
+
+type options struct {
+	timestamp  time.Time
+	source     string
+	importPath string
+}
+
+// SetTimestamp is an Option that sets the timestamp of the created report.
+func SetTimestamp(t time.Time) Option {
+	return func(o *options) {
+		o.timestamp = t
+	}
+}
+
+// SetSource is an Option that adds dir as the source directory of the files
+// in the package with the given import path and its subpackages. The
+// filenames of these files are made relative to dir.
+func SetSource(dir, importPath string) Option {
+	return func(o *options) {
+		o.source = dir
+		o.importPath = importPath
+	}
+}
+
+// CreateFromCoverage creates a Cobertura report from the given coverage.
+// Each package of the covered files becomes a package in the report, and each
+// file a class. Branch coverage and complexity are not available and reported
+// as 0.
+func CreateFromCoverage(coverage gtr.Coverage, opts ...Option) Coverage {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	c := Coverage{BranchRate: "0", Complexity: "0"}
+	if !o.timestamp.IsZero() {
+		c.Timestamp = o.timestamp.UnixNano() / int64(time.Millisecond)
+	}
+	if o.source != "" {
+		c.Sources = []string{o.source}
+	}
+
+	packages := make(map[string]int)
+	for _, file := range coverage.Files {
+		name := path.Dir(file.Name)
+		i, ok := packages[name]
+		if !ok {
+			i = len(c.Packages)
+			packages[name] = i
+			c.Packages = append(c.Packages, Package{Name: name, BranchRate: "0", Complexity: "0"})
+		}
+
+		class := Class{
+			Name:       path.Base(file.Name),
+			Filename:   o.filename(file.Name),
+			BranchRate: "0",
+			Complexity: "0",
+		}
+		covered := 0
+		for _, line := range file.Lines() {
+			class.Lines = append(class.Lines, Line{Number: line.Line, Hits: line.Count})
+			if line.Count > 0 {
+				covered++
+			}
+		}
+		class.LineRate = rate(covered, len(class.Lines))
+
+		pkg := &c.Packages[i]
+		pkg.Classes = append(pkg.Classes, class)
+		pkg.linesCovered += covered
+		pkg.linesValid += len(class.Lines)
+	}
+
+	for i := range c.Packages {
+		pkg := &c.Packages[i]
+		pkg.LineRate = rate(pkg.linesCovered, pkg.linesValid)
+		c.LinesCovered += pkg.linesCovered
+		c.LinesValid += pkg.linesValid
+	}
+	c.LineRate = rate(c.LinesCovered, c.LinesValid)
+	return c
+}
+
+// filename returns the filename of the file with the given name, relative to
+// the source directory if it belongs to the source package.
+func (o options) filename(name string) string {
+	if o.importPath != "" && strings.HasPrefix(name, o.importPath+"/") {
+		return strings.TrimPrefix(name, o.importPath+"/")
+	}
+	return name
+}
+
+// rate returns covered/valid formatted as a Cobertura rate. Like Cobertura,
+// the rate is 1 if there are no lines.
+func rate(covered, valid int) string {
+	if valid == 0 {
+		return "1"
+	}
+	return fmt.Sprintf("%.4f", float64(covered)/float64(valid))
+}
@@ -0,0 +1,78 @@
+package cobertura
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/jstemmer/go-junit-report/v2/gtr"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCreateFromCoverage(t *testing.T) {
+	coverage := gtr.Coverage{
+		Mode: "count",
+		Files: []gtr.CoverageFile{
+			{
+				Name: "example.com/mod/pkg/a.go",
+				Blocks: []gtr.CoverageBlock{
+					{StartLine: 3, StartCol: 14, EndLine: 5, EndCol: 2, Statements: 2, Count: 4},
+					{StartLine: 7, StartCol: 2, EndLine: 7, EndCol: 20, Statements: 1, Count: 0},
+				},
+			},
+			{
+				Name: "example.org/other/b.go",
+				Blocks: []gtr.CoverageBlock{
+					{StartLine: 1, StartCol: 1, EndLine: 1, EndCol: 10, Statements: 1, Count: 1},
+				},
+			},
+		},
+	}
+
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE coverage SYSTEM "http://cobertura.sourceforge.net/xml/coverage-04.dtd">
+<coverage line-rate="0.8000" branch-rate="0" lines-covered="4" lines-valid="5" branches-covered="0" branches-valid="0" complexity="0" version="" timestamp="1640995200000">
+	<sources>
+		<source>/src/mod</source>
+	</sources>
+	<packages>
+		<package name="example.com/mod/pkg" line-rate="0.7500" branch-rate="0" complexity="0">
+			<classes>
+				<class name="a.go" filename="pkg/a.go" line-rate="0.7500" branch-rate="0" complexity="0">
+					<methods></methods>
+					<lines>
+						<line number="3" hits="4"></line>
+						<line number="4" hits="4"></line>
+						<line number="5" hits="4"></line>
+						<line number="7" hits="0"></line>
+					</lines>
+				</class>
+			</classes>
+		</package>
+		<package name="example.org/other" line-rate="1.0000" branch-rate="0" complexity="0">
+			<classes>
+				<class name="b.go" filename="example.org/other/b.go" line-rate="1.0000" branch-rate="0" complexity="0">
+					<methods></methods>
+					<lines>
+						<line number="1" hits="1"></line>
+					</lines>
+				</class>
+			</classes>
+		</package>
+	</packages>
+</coverage>
+`
+
+	c := CreateFromCoverage(coverage,
+		SetTimestamp(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)),
+		SetSource("/src/mod", "example.com/mod"))
+
+	var buf bytes.Buffer
+	if err := c.WriteXML(&buf); err != nil {
+		t.Fatalf("WriteXML failed: %v", err)
+	}
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("Incorrect report created, diff (-want, +got):\n%s\n", diff)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jstemmer/go-junit-report/v2/gtr"
+	"github.com/jstemmer/go-junit-report/v2/internal/gojunitreport"
+)
+
+// runDiff runs the diff subcommand, which compares the tests in two reports.
+func runDiff(args []string) {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	format := fs.String("format", "text", "set output `format`: text, json")
+	output := fs.String("out", "", "write the differences to `file`")
+	failOn := fs.String("fail-on", string(gojunitreport.DiffNewlyFailing), "exit with code 1 if any tests have one of the comma separated `statuses`: newly-failing, newly-passing, still-failing, added, removed, newly-skipped, slower; or none")
+	slower := fs.Float64("slower-threshold", gojunitreport.DefaultSlowerThreshold.Relative, "report passing tests whose duration increased by more than this fraction as slower, negative to disable")
+	slowerMin := fs.Duration("slower-min", gojunitreport.DefaultSlowerThreshold.Min, "only report tests as slower if their duration increased by at least `duration`")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s diff [flags] old new\n\n", os.Args[0])
+		fmt.Fprintf(fs.Output(), "Compares the tests in the old and new JUnit XML, JSON or go test reports and\nlists the tests that were newly failing, newly passing, still failing, added,\nremoved, newly skipped or slower.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		exitf("")
+	}
+
+	var write func(io.Writer, []gojunitreport.TestDiff) error
+	switch *format {
+	case "text":
+		write = gojunitreport.WriteDiffText
+	case "json":
+		write = gojunitreport.WriteDiffJSON
+	default:
+		exitf("invalid value for -format: %s", *format)
+	}
+
+	failStatuses := make(map[gojunitreport.DiffStatus]bool)
+	if *failOn != "none" && *failOn != "" {
+		for _, s := range strings.Split(*failOn, ",") {
+			status, err := gojunitreport.ParseDiffStatus(strings.TrimSpace(s))
+			if err != nil {
+				exitf("invalid value for -fail-on: %s", err)
+			}
+			failStatuses[status] = true
+		}
+	}
+
+	oldReport, err := readReportFile(fs.Arg(0))
+	if err != nil {
+		exitf("error reading old report: %v", err)
+	}
+	newReport, err := readReportFile(fs.Arg(1))
+	if err != nil {
+		exitf("error reading new report: %v", err)
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		if out, err = os.Create(*output); err != nil {
+			exitf("error creating output file: %v", err)
+		}
+	}
+
+	diffs := gojunitreport.DiffReports(oldReport, newReport, gojunitreport.SlowerThreshold{Relative: *slower, Min: *slowerMin})
+	err = write(out, diffs)
+	if out != os.Stdout {
+		out.Close()
+	}
+	if err != nil {
+		exitf("error writing differences: %v", err)
+	}
+
+	for _, d := range diffs {
+		if failStatuses[d.Status] {
+			os.Exit(1)
+		}
+	}
+}
+
+// readReportFile reads a JUnit XML, gtr JSON or go test report from the file
+// with the given name.
+func readReportFile(name string) (gtr.Report, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return gtr.Report{}, err
+	}
+	defer f.Close()
+	return gojunitreport.ReadReport(f)
+}
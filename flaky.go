@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jstemmer/go-junit-report/v2/internal/gojunitreport"
+)
+
+// runFlaky runs the flaky subcommand, which finds flaky tests in a directory
+// of previously created reports.
+func runFlaky(args []string) {
+	fs := flag.NewFlagSet("flaky", flag.ExitOnError)
+	format := fs.String("format", "markdown", "set output `format`: markdown, json")
+	minRuns := fs.Int("min-runs", 2, "ignore tests that passed or failed fewer than `n` times")
+	output := fs.String("out", "", "write flaky test report to `file`")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s flaky [flags] dir\n\n", os.Args[0])
+		fmt.Fprintf(fs.Output(), "Reads all JUnit XML (.xml) and JSON (.json) reports in dir and lists the\ntests that both passed and failed, ranked by how often their result changed.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		exitf("")
+	}
+
+	var write func(io.Writer, []gojunitreport.FlakyTest) error
+	switch *format {
+	case "markdown":
+		write = gojunitreport.WriteFlakyMarkdown
+	case "json":
+		write = gojunitreport.WriteFlakyJSON
+	default:
+		exitf("invalid value for -format: %s", *format)
+	}
+
+	reports, err := gojunitreport.ReadReportDir(fs.Arg(0))
+	if err != nil {
+		exitf("error reading reports: %v", err)
+	}
+
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			exitf("error creating output file: %v", err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	if err := write(out, gojunitreport.FindFlakyTests(reports, *minRuns)); err != nil {
+		exitf("error writing flaky tests: %v", err)
+	}
+}
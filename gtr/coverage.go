@@ -0,0 +1,128 @@
+package gtr
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Coverage contains the statement coverage of source files, as recorded in
+// the profiles written by `go test -coverprofile`.
+type Coverage struct {
+	Mode  string // set, count or atomic
+	Files []CoverageFile
+}
+
+// CoverageFile contains the coverage of a single source file.
+type CoverageFile struct {
+	Name   string // import path of the package followed by the file name
+	Blocks []CoverageBlock
+}
+
+// CoverageBlock contains the number of times a block of statements was
+// executed. In set mode, Count is 1 if the block was executed and 0
+// otherwise.
+type CoverageBlock struct {
+	StartLine, StartCol int
+	EndLine, EndCol     int
+	Statements          int
+	Count               int64
+}
+
+// LineCoverage contains the number of times a line was executed.
+type LineCoverage struct {
+	Line  int
+	Count int64
+}
+
+// Lines returns the lines of f that contain statements, ordered by line
+// number. A line that is part of multiple blocks gets the highest count of
+// those blocks. Since the end column of a block is exclusive, a block that
+// ends in the first column of a line does not include that line.
+func (f CoverageFile) Lines() []LineCoverage {
+	counts := make(map[int]int64)
+	for _, b := range f.Blocks {
+		if b.Statements == 0 {
+			continue
+		}
+		end := b.EndLine
+		if b.EndCol <= 1 && end > b.StartLine {
+			end--
+		}
+		for line := b.StartLine; line <= end; line++ {
+			if count, ok := counts[line]; !ok || b.Count > count {
+				counts[line] = b.Count
+			}
+		}
+	}
+
+	lines := make([]LineCoverage, 0, len(counts))
+	for line, count := range counts {
+		lines = append(lines, LineCoverage{Line: line, Count: count})
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		return lines[i].Line < lines[j].Line
+	})
+	return lines
+}
+
+// Merge merges the coverage in other into c. The counts of blocks that
+// appear in both are added together, or combined in set mode. Merge returns
+// an error if the modes of c and other are different.
+func (c *Coverage) Merge(other Coverage) error {
+	if c.Mode == "" {
+		c.Mode = other.Mode
+	} else if other.Mode != "" && other.Mode != c.Mode {
+		return fmt.Errorf("cannot merge coverage with mode %q into coverage with mode %q", other.Mode, c.Mode)
+	}
+
+	files := make(map[string]int)
+	for i, f := range c.Files {
+		files[f.Name] = i
+	}
+	for _, f := range other.Files {
+		i, ok := files[f.Name]
+		if !ok {
+			i = len(c.Files)
+			files[f.Name] = i
+			c.Files = append(c.Files, CoverageFile{Name: f.Name})
+		}
+		c.Files[i].Blocks = mergeBlocks(c.Files[i].Blocks, f.Blocks, c.Mode == "set")
+	}
+
+	sort.Slice(c.Files, func(i, j int) bool {
+		return c.Files[i].Name < c.Files[j].Name
+	})
+	return nil
+}
+
+// mergeBlocks returns the blocks of a and b ordered by their position, with
+// the counts of identical blocks combined.
+func mergeBlocks(a, b []CoverageBlock, set bool) []CoverageBlock {
+	type position struct{ startLine, startCol, endLine, endCol int }
+	index := make(map[position]int)
+	var merged []CoverageBlock
+	for _, block := range append(append([]CoverageBlock(nil), a...), b...) {
+		pos := position{block.StartLine, block.StartCol, block.EndLine, block.EndCol}
+		i, ok := index[pos]
+		if !ok {
+			index[pos] = len(merged)
+			merged = append(merged, block)
+			continue
+		}
+		if set {
+			if block.Count > 0 {
+				merged[i].Count = 1
+			}
+		} else {
+			merged[i].Count += block.Count
+		}
+	}
+
+	sort.Slice(merged, func(i, j int) bool {
+		if merged[i].StartLine != merged[j].StartLine {
+			return merged[i].StartLine < merged[j].StartLine
+		}
+		return merged[i].StartCol < merged[j].StartCol
+	})
+	return merged
+}
@@ -3,6 +3,7 @@
 package gtr
 
 import (
+	"fmt"
 	"strings"
 	"time"
 )
@@ -33,9 +34,65 @@ func (r Result) String() string {
 	}
 }
 
+// Kind is the kind of a test, which is determined by the prefix of the name of
+// its top-level test function.
+type Kind int
+
+// Test kinds.
+const (
+	KindTest Kind = iota
+	KindBenchmark
+	KindExample
+	KindFuzz
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindTest:
+		return "test"
+	case KindBenchmark:
+		return "benchmark"
+	case KindExample:
+		return "example"
+	case KindFuzz:
+		return "fuzz"
+	default:
+		panic("invalid Kind")
+	}
+}
+
+// ParseKind returns the Kind for the given string, which should be one of
+// "test", "benchmark", "example" or "fuzz".
+func ParseKind(s string) (Kind, error) {
+	for _, k := range []Kind{KindTest, KindBenchmark, KindExample, KindFuzz} {
+		if s == k.String() {
+			return k, nil
+		}
+	}
+	return KindTest, fmt.Errorf("unknown test kind: %v", s)
+}
+
+// KindOf returns the Kind of a test with the given name.
+func KindOf(name string) Kind {
+	switch {
+	case strings.HasPrefix(name, "Benchmark"):
+		return KindBenchmark
+	case strings.HasPrefix(name, "Example"):
+		return KindExample
+	case strings.HasPrefix(name, "Fuzz"):
+		return KindFuzz
+	default:
+		return KindTest
+	}
+}
+
 // Report contains the build and test results of a collection of packages.
 type Report struct {
 	Packages []Package
+
+	// Coverage optionally contains the statement coverage of the source
+	// files of the packages.
+	Coverage *Coverage `json:",omitempty"`
 }
 
 // IsSuccessful returns true if none of the packages in this report have build
@@ -61,12 +118,17 @@ type Package struct {
 	Duration   time.Duration
 	Coverage   float64
 	Output     []string
+	Stderr     []string // output written to stderr, if captured separately
 	Properties []Property
 
 	Tests []Test
 
 	BuildError Error
 	RunError   Error
+
+	// HasCoverage is true if the coverage percentage of this package was
+	// reported, which distinguishes a Coverage of 0% from no coverage data.
+	HasCoverage bool
 }
 
 // SetProperty stores a key/value property in the current package. If a
@@ -92,6 +154,102 @@ func (p *Package) AddProperty(name, value string) {
 	p.Properties = append(p.Properties, Property{Name: name, Value: value})
 }
 
+// GroupAttempts merges tests with the same name into a single test, whose
+// Attempts contain every run of that test. This is useful when tests were run
+// more than once, for example using `go test -count` or a wrapper that retries
+// failed tests. The merged test is a copy of the last attempt, so it only
+// passes if the last attempt passed, in which case it is flaky if an earlier
+// attempt failed. Tests that only ran once are not changed.
+func (p *Package) GroupAttempts() {
+	index := make(map[string]int)
+	ids := make(map[int]int) // attempt id -> merged id
+	var tests []Test
+	for _, t := range p.Tests {
+		i, ok := index[t.Name]
+		if !ok {
+			index[t.Name] = len(tests)
+			tests = append(tests, t)
+			continue
+		}
+
+		attempts := tests[i].Attempts
+		if attempts == nil {
+			attempts = []Test{tests[i]}
+		}
+		attempts = append(attempts, t)
+
+		merged := attempts[len(attempts)-1]
+		merged.ID = attempts[0].ID
+		merged.Attempts = attempts
+		tests[i] = merged
+		ids[t.ID] = merged.ID
+	}
+
+	// Subtests of later attempts should refer to the merged parent.
+	for i := range tests {
+		if id, ok := ids[tests[i].ParentID]; ok {
+			tests[i].ParentID = id
+		}
+	}
+	p.Tests = tests
+}
+
+// TestNode is a node in the tree of tests in a package.
+type TestNode struct {
+	Test     Test
+	Children []*TestNode
+}
+
+// Result returns the result of this test, rolled up from its children: if
+// this test or any of its subtests failed, the result is Fail. Otherwise the
+// result of the test itself is returned.
+func (n *TestNode) Result() Result {
+	if n.Test.Result == Fail {
+		return Fail
+	}
+	for _, c := range n.Children {
+		if c.Result() == Fail {
+			return Fail
+		}
+	}
+	return n.Test.Result
+}
+
+// Walk calls fn for this node and all of its descendants in depth-first
+// order. The depth of n is 0.
+func (n *TestNode) Walk(fn func(node *TestNode, depth int)) {
+	n.walk(fn, 0)
+}
+
+func (n *TestNode) walk(fn func(node *TestNode, depth int), depth int) {
+	fn(n, depth)
+	for _, c := range n.Children {
+		c.walk(fn, depth+1)
+	}
+}
+
+// TestTree returns the tests in this package as a tree, using the ParentID of
+// each test to find its parent. The top-level tests are returned in the order
+// in which they appear in Tests, as are the children of each node. Tests whose
+// parent is not part of this package are returned as top-level tests.
+func (p Package) TestTree() []*TestNode {
+	nodes := make(map[int]*TestNode, len(p.Tests))
+	for _, t := range p.Tests {
+		nodes[t.ID] = &TestNode{Test: t}
+	}
+
+	var roots []*TestNode
+	for _, t := range p.Tests {
+		node := nodes[t.ID]
+		if parent, ok := nodes[t.ParentID]; ok && t.ParentID != 0 && parent != node {
+			parent.Children = append(parent.Children, node)
+		} else {
+			roots = append(roots, node)
+		}
+	}
+	return roots
+}
+
 // Property is a name/value property.
 type Property struct {
 	Name, Value string
@@ -99,18 +257,66 @@ type Property struct {
 
 // Test contains the results of a single test.
 type Test struct {
-	ID       int
-	Name     string
-	Duration time.Duration
-	Result   Result
-	Level    int
-	Output   []string
-	Data     map[string]interface{}
+	ID         int
+	ParentID   int // ID of the parent test for subtests, 0 otherwise
+	Name       string
+	Kind       Kind
+	Duration   time.Duration
+	Result     Result
+	Level      int
+	Output     []string
+	Stderr     []string // output written to stderr, if captured separately
+	Properties []Property
+
+	// Data contains parser specific data. It is not included when the report
+	// is written as JSON.
+	Data map[string]interface{} `json:"-"`
+
+	// File and Line contain the location of the test function declaration,
+	// if known.
+	File string
+	Line int
+
+	// Failure contains additional details in case this test failed.
+	Failure Failure
+
+	// Attempts contains every run of this test, in the order they were run,
+	// when the test was run more than once. See Package.GroupAttempts.
+	Attempts []Test
+}
+
+// IsFlaky returns true if this test passed in one attempt and failed in
+// another.
+func (t Test) IsFlaky() bool {
+	if t.Result != Pass {
+		return false
+	}
+	for _, a := range t.Attempts {
+		if a.Result == Fail {
+			return true
+		}
+	}
+	return false
 }
 
 // NewTest creates a new Test with the given id and name.
 func NewTest(id int, name string) Test {
-	return Test{ID: id, Name: name, Data: make(map[string]interface{})}
+	return Test{ID: id, Name: name, Kind: KindOf(name), Data: make(map[string]interface{})}
+}
+
+// AddProperty appends a name/value property in the current test.
+func (t *Test) AddProperty(name, value string) {
+	t.Properties = append(t.Properties, Property{Name: name, Value: value})
+}
+
+// Failure contains details of a test failure.
+type Failure struct {
+	Type    string
+	Message string
+
+	// File and Line contain the source location of the failure, if known.
+	File string
+	Line int
 }
 
 // Error contains details of a build or runtime error.
@@ -1,7 +1,10 @@
 package gtr
 
 import (
+	"bytes"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 )
@@ -40,3 +43,202 @@ func TestSetProperty(t *testing.T) {
 		t.Errorf("SetProperty got unexpected diff: %s", diff)
 	}
 }
+
+func TestGroupAttempts(t *testing.T) {
+	pkg := Package{
+		Tests: []Test{
+			{ID: 1, Name: "TestFlaky", Result: Fail},
+			{ID: 2, Name: "TestPass", Result: Pass},
+			{ID: 3, Name: "TestFail", Result: Fail, Output: []string{"first"}},
+			{ID: 4, Name: "TestFlaky", Result: Pass},
+			{ID: 5, Name: "TestFail", Result: Fail, Output: []string{"second"}},
+			{ID: 6, Name: "TestFailLast", Result: Fail},
+			{ID: 7, Name: "TestFailLast", Result: Pass},
+			{ID: 8, Name: "TestFailLast", Result: Fail, Output: []string{"last"}},
+		},
+	}
+
+	want := []Test{
+		{
+			ID:     1,
+			Name:   "TestFlaky",
+			Result: Pass,
+			Attempts: []Test{
+				{ID: 1, Name: "TestFlaky", Result: Fail},
+				{ID: 4, Name: "TestFlaky", Result: Pass},
+			},
+		},
+		{ID: 2, Name: "TestPass", Result: Pass},
+		{
+			ID:     3,
+			Name:   "TestFail",
+			Result: Fail,
+			Output: []string{"second"},
+			Attempts: []Test{
+				{ID: 3, Name: "TestFail", Result: Fail, Output: []string{"first"}},
+				{ID: 5, Name: "TestFail", Result: Fail, Output: []string{"second"}},
+			},
+		},
+		{
+			ID:     6,
+			Name:   "TestFailLast",
+			Result: Fail,
+			Output: []string{"last"},
+			Attempts: []Test{
+				{ID: 6, Name: "TestFailLast", Result: Fail},
+				{ID: 7, Name: "TestFailLast", Result: Pass},
+				{ID: 8, Name: "TestFailLast", Result: Fail, Output: []string{"last"}},
+			},
+		},
+	}
+
+	pkg.GroupAttempts()
+	if diff := cmp.Diff(want, pkg.Tests); diff != "" {
+		t.Errorf("GroupAttempts got unexpected diff (-want +got):\n%s", diff)
+	}
+
+	for i, flaky := range []bool{true, false, false, false} {
+		if got := pkg.Tests[i].IsFlaky(); got != flaky {
+			t.Errorf("%s.IsFlaky() = %v, want %v", pkg.Tests[i].Name, got, flaky)
+		}
+	}
+}
+
+func TestTestTree(t *testing.T) {
+	pkg := Package{
+		Tests: []Test{
+			{ID: 1, Name: "TestA", Result: Pass},
+			{ID: 2, ParentID: 1, Name: "TestA/case_1", Result: Pass},
+			{ID: 3, ParentID: 2, Name: "TestA/case_1/sub", Result: Fail},
+			{ID: 4, ParentID: 1, Name: "TestA/case_2", Result: Skip},
+			{ID: 5, Name: "TestB", Result: Skip},
+			{ID: 6, ParentID: 99, Name: "TestC/orphan", Result: Pass},
+		},
+	}
+
+	type node struct {
+		name   string
+		depth  int
+		result Result
+	}
+	want := []node{
+		{"TestA", 0, Fail},
+		{"TestA/case_1", 1, Fail},
+		{"TestA/case_1/sub", 2, Fail},
+		{"TestA/case_2", 1, Skip},
+		{"TestB", 0, Skip},
+		{"TestC/orphan", 0, Pass},
+	}
+
+	var got []node
+	for _, root := range pkg.TestTree() {
+		root.Walk(func(n *TestNode, depth int) {
+			got = append(got, node{n.Test.Name, depth, n.Result()})
+		})
+	}
+	if diff := cmp.Diff(want, got, cmp.AllowUnexported(node{})); diff != "" {
+		t.Errorf("TestTree got unexpected diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestGroupAttemptsParentID(t *testing.T) {
+	pkg := Package{
+		Tests: []Test{
+			{ID: 1, Name: "TestA", Result: Fail},
+			{ID: 2, ParentID: 1, Name: "TestA/sub", Result: Fail},
+			{ID: 3, Name: "TestA", Result: Pass},
+			{ID: 4, ParentID: 3, Name: "TestA/sub", Result: Pass},
+		},
+	}
+	pkg.GroupAttempts()
+
+	if len(pkg.Tests) != 2 {
+		t.Fatalf("GroupAttempts returned %d tests, want 2", len(pkg.Tests))
+	}
+	if got := pkg.Tests[1].ParentID; got != 1 {
+		t.Errorf("GroupAttempts subtest ParentID = %d, want 1", got)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	report := Report{
+		Packages: []Package{
+			{
+				Name:       "package/name",
+				Timestamp:  time.Date(2022, 6, 26, 0, 0, 0, 0, time.UTC),
+				Duration:   time.Second,
+				Properties: []Property{{Name: "go.version", Value: "1.18"}},
+				Tests: []Test{
+					{ID: 1, Name: "TestOne", Result: Pass, Data: map[string]interface{}{}},
+					{
+						ID:      2,
+						Name:    "BenchmarkTwo",
+						Kind:    KindBenchmark,
+						Result:  Fail,
+						Output:  []string{"output"},
+						Data:    map[string]interface{}{},
+						Failure: Failure{Type: "panic", Message: "oops"},
+						Attempts: []Test{
+							{ID: 2, Name: "BenchmarkTwo", Kind: KindBenchmark, Result: Skip, Data: map[string]interface{}{}},
+						},
+					},
+				},
+				RunError: Error{Name: "package/name", Output: []string{"exit status 1"}},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"Result": "FAIL"`) {
+		t.Errorf("WriteJSON did not write result as string:\n%s", buf.String())
+	}
+
+	got, err := ReadJSON(&buf)
+	if err != nil {
+		t.Fatalf("ReadJSON failed: %v", err)
+	}
+	if diff := cmp.Diff(report, got); diff != "" {
+		t.Errorf("ReadJSON got unexpected diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestCoverageMerge(t *testing.T) {
+	file := func(counts ...int64) CoverageFile {
+		f := CoverageFile{Name: "example.com/pkg/file.go"}
+		for i, count := range counts {
+			f.Blocks = append(f.Blocks, CoverageBlock{StartLine: i + 1, EndLine: i + 1, Statements: 1, Count: count})
+		}
+		return f
+	}
+
+	coverage := Coverage{Mode: "set", Files: []CoverageFile{file(1, 0, 0)}}
+	if err := coverage.Merge(Coverage{Mode: "set", Files: []CoverageFile{file(1, 1, 0)}}); err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	want := Coverage{Mode: "set", Files: []CoverageFile{file(1, 1, 0)}}
+	if diff := cmp.Diff(want, coverage); diff != "" {
+		t.Errorf("Merge incorrect, diff (-want, +got):\n%s\n", diff)
+	}
+
+	if err := coverage.Merge(Coverage{Mode: "count"}); err == nil {
+		t.Errorf("Merge did not return an error for different modes")
+	}
+}
+
+func TestCoverageLines(t *testing.T) {
+	file := CoverageFile{
+		Blocks: []CoverageBlock{
+			{StartLine: 1, StartCol: 5, EndLine: 3, EndCol: 10, Statements: 2, Count: 0},
+			{StartLine: 3, StartCol: 10, EndLine: 4, EndCol: 5, Statements: 1, Count: 5},
+			{StartLine: 5, StartCol: 2, EndLine: 7, EndCol: 1, Statements: 1, Count: 1},
+			{StartLine: 9, StartCol: 2, EndLine: 9, EndCol: 8, Statements: 0, Count: 0},
+		},
+	}
+	want := []LineCoverage{{1, 0}, {2, 0}, {3, 5}, {4, 5}, {5, 1}, {6, 1}}
+	if diff := cmp.Diff(want, file.Lines()); diff != "" {
+		t.Errorf("Lines incorrect, diff (-want, +got):\n%s\n", diff)
+	}
+}
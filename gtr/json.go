@@ -0,0 +1,67 @@
+package gtr
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// WriteJSON writes the report to w in JSON format. Parser specific test data
+// is not included.
+func (r Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(r)
+}
+
+// ReadJSON reads a report in the JSON format written by WriteJSON from r.
+func ReadJSON(r io.Reader) (Report, error) {
+	var report Report
+	if err := json.NewDecoder(r).Decode(&report); err != nil {
+		return Report{}, err
+	}
+	for i := range report.Packages {
+		for j := range report.Packages[i].Tests {
+			initData(&report.Packages[i].Tests[j])
+		}
+	}
+	return report, nil
+}
+
+func initData(t *Test) {
+	t.Data = make(map[string]interface{})
+	for i := range t.Attempts {
+		initData(&t.Attempts[i])
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (r Result) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (r *Result) UnmarshalText(text []byte) error {
+	for _, result := range []Result{Unknown, Pass, Fail, Skip} {
+		if string(text) == result.String() {
+			*r = result
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown test result: %s", text)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (k Kind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (k *Kind) UnmarshalText(text []byte) error {
+	kind, err := ParseKind(string(text))
+	if err != nil {
+		return err
+	}
+	*k = kind
+	return nil
+}
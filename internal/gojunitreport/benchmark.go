@@ -0,0 +1,136 @@
+package gojunitreport
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jstemmer/go-junit-report/v2/gtr"
+)
+
+// BenchmarkThresholds contains the maximum relative increase of benchmark
+// metrics compared to their baseline values. For example, a threshold of 0.1
+// allows a metric to become up to 10% worse before the benchmark is marked as
+// failed. A negative threshold disables the check for that metric.
+type BenchmarkThresholds struct {
+	NsPerOp     float64
+	BytesPerOp  float64
+	AllocsPerOp float64
+}
+
+// DefaultBenchmarkThresholds are the default benchmark regression thresholds.
+var DefaultBenchmarkThresholds = BenchmarkThresholds{
+	NsPerOp:     0.1,
+	BytesPerOp:  0.1,
+	AllocsPerOp: 0.1,
+}
+
+// benchmarkKey identifies a benchmark by its package, its name without the
+// GOMAXPROCS suffix and its GOMAXPROCS value, which is 0 if unknown.
+type benchmarkKey struct {
+	pkg, name string
+	cpu       int
+}
+
+// compareBenchmarks compares the benchmarks in report with those of the same
+// name and GOMAXPROCS value in baseline. The "-N" suffix of benchmark names is
+// ignored, since it is only added when benchmarks ran with several GOMAXPROCS
+// values. Benchmarks that regressed beyond the given thresholds are marked as
+// failed.
+func compareBenchmarks(report *gtr.Report, baseline gtr.Report, thresholds BenchmarkThresholds) {
+	baselineMetrics := make(map[benchmarkKey]map[string]float64)
+	for _, pkg := range baseline.Packages {
+		for _, test := range pkg.Tests {
+			if metrics := benchmarkMetrics(test); len(metrics) > 0 {
+				baselineMetrics[newBenchmarkKey(pkg.Name, test)] = metrics
+			}
+		}
+	}
+
+	checks := []struct {
+		unit      string
+		threshold float64
+	}{
+		{"ns/op", thresholds.NsPerOp},
+		{"B/op", thresholds.BytesPerOp},
+		{"allocs/op", thresholds.AllocsPerOp},
+	}
+
+	for i := range report.Packages {
+		pkg := &report.Packages[i]
+		for j := range pkg.Tests {
+			test := &pkg.Tests[j]
+			if test.Result != gtr.Pass {
+				continue
+			}
+			key := newBenchmarkKey(pkg.Name, *test)
+			old, ok := baselineMetrics[key]
+			if !ok && key.cpu > 0 {
+				// Baselines without a GOMAXPROCS value are matched by name.
+				old, ok = baselineMetrics[benchmarkKey{pkg: pkg.Name, name: test.Name}]
+			}
+			if !ok {
+				continue
+			}
+
+			current := benchmarkMetrics(*test)
+			var regressions []string
+			for _, check := range checks {
+				oldValue, ok1 := old[check.unit]
+				newValue, ok2 := current[check.unit]
+				if !ok1 || !ok2 || check.threshold < 0 {
+					continue
+				}
+				if newValue > oldValue*(1+check.threshold) {
+					regressions = append(regressions, formatRegression(check.unit, oldValue, newValue, check.threshold))
+				}
+			}
+
+			if len(regressions) > 0 {
+				test.Result = gtr.Fail
+				test.Failure = gtr.Failure{
+					Type:    "regression",
+					Message: "Benchmark regressed: " + strings.Join(regressions, "; "),
+				}
+			}
+		}
+	}
+}
+
+// newBenchmarkKey returns the benchmarkKey of test in pkg. The GOMAXPROCS
+// value is taken from the "benchmark.cpu" property.
+func newBenchmarkKey(pkg string, test gtr.Test) benchmarkKey {
+	key := benchmarkKey{pkg: pkg, name: test.Name}
+	for _, prop := range test.Properties {
+		if prop.Name == "benchmark.cpu" {
+			key.cpu, _ = strconv.Atoi(prop.Value)
+		}
+	}
+	if key.cpu > 0 {
+		key.name = strings.TrimSuffix(key.name, "-"+strconv.Itoa(key.cpu))
+	}
+	return key
+}
+
+// benchmarkMetrics returns the benchmark metrics stored in the properties of
+// the given test.
+func benchmarkMetrics(test gtr.Test) map[string]float64 {
+	metrics := make(map[string]float64)
+	for _, prop := range test.Properties {
+		switch prop.Name {
+		case "ns/op", "B/op", "allocs/op":
+			if value, err := strconv.ParseFloat(prop.Value, 64); err == nil {
+				metrics[prop.Name] = value
+			}
+		}
+	}
+	return metrics
+}
+
+func formatRegression(unit string, oldValue, newValue, threshold float64) string {
+	if oldValue == 0 {
+		return fmt.Sprintf("%s increased from %g to %g", unit, oldValue, newValue)
+	}
+	delta := (newValue - oldValue) / oldValue * 100
+	return fmt.Sprintf("%s increased by %.1f%% from %g to %g (threshold %.1f%%)", unit, delta, oldValue, newValue, threshold*100)
+}
@@ -0,0 +1,103 @@
+package gojunitreport
+
+import (
+	"testing"
+
+	"github.com/jstemmer/go-junit-report/v2/gtr"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCompareBenchmarks(t *testing.T) {
+	benchmark := func(name string, result gtr.Result, props ...string) gtr.Test {
+		test := gtr.Test{Name: name, Result: result}
+		for i := 0; i+1 < len(props); i += 2 {
+			test.AddProperty(props[i], props[i+1])
+		}
+		return test
+	}
+
+	baseline := gtr.Report{Packages: []gtr.Package{{
+		Name: "package/bench",
+		Tests: []gtr.Test{
+			benchmark("BenchmarkFaster", gtr.Pass, "ns/op", "100"),
+			benchmark("BenchmarkSlower", gtr.Pass, "ns/op", "100", "B/op", "64"),
+			benchmark("BenchmarkAllocs", gtr.Pass, "ns/op", "100", "allocs/op", "0"),
+			benchmark("BenchmarkWithinThreshold", gtr.Pass, "ns/op", "100"),
+		},
+	}}}
+
+	report := gtr.Report{Packages: []gtr.Package{{
+		Name: "package/bench",
+		Tests: []gtr.Test{
+			benchmark("BenchmarkFaster", gtr.Pass, "ns/op", "50"),
+			benchmark("BenchmarkSlower", gtr.Pass, "ns/op", "150", "B/op", "128"),
+			benchmark("BenchmarkAllocs", gtr.Pass, "ns/op", "100", "allocs/op", "1"),
+			benchmark("BenchmarkWithinThreshold", gtr.Pass, "ns/op", "109"),
+			benchmark("BenchmarkNew", gtr.Pass, "ns/op", "1000"),
+		},
+	}}}
+
+	want := gtr.Report{Packages: []gtr.Package{{
+		Name: "package/bench",
+		Tests: []gtr.Test{
+			benchmark("BenchmarkFaster", gtr.Pass, "ns/op", "50"),
+			benchmark("BenchmarkSlower", gtr.Fail, "ns/op", "150", "B/op", "128"),
+			benchmark("BenchmarkAllocs", gtr.Fail, "ns/op", "100", "allocs/op", "1"),
+			benchmark("BenchmarkWithinThreshold", gtr.Pass, "ns/op", "109"),
+			benchmark("BenchmarkNew", gtr.Pass, "ns/op", "1000"),
+		},
+	}}}
+	want.Packages[0].Tests[1].Failure = gtr.Failure{
+		Type:    "regression",
+		Message: "Benchmark regressed: ns/op increased by 50.0% from 100 to 150 (threshold 10.0%); B/op increased by 100.0% from 64 to 128 (threshold 10.0%)",
+	}
+	want.Packages[0].Tests[2].Failure = gtr.Failure{
+		Type:    "regression",
+		Message: "Benchmark regressed: allocs/op increased from 0 to 1",
+	}
+
+	compareBenchmarks(&report, baseline, DefaultBenchmarkThresholds)
+	if diff := cmp.Diff(want, report); diff != "" {
+		t.Errorf("compareBenchmarks produced unexpected report, diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestCompareBenchmarksCPU(t *testing.T) {
+	benchmark := func(name, cpu, nsPerOp string) gtr.Test {
+		test := gtr.Test{Name: name, Result: gtr.Pass}
+		test.AddProperty("ns/op", nsPerOp)
+		if cpu != "" {
+			test.AddProperty("benchmark.cpu", cpu)
+		}
+		return test
+	}
+
+	// The baseline ran with a single GOMAXPROCS value, so its names have no
+	// suffix, while the report ran with several.
+	baseline := gtr.Report{Packages: []gtr.Package{{
+		Name: "package/bench",
+		Tests: []gtr.Test{
+			benchmark("BenchmarkOne", "16", "100"),
+			benchmark("BenchmarkOld-4", "", "100"),
+		},
+	}}}
+	report := gtr.Report{Packages: []gtr.Package{{
+		Name: "package/bench",
+		Tests: []gtr.Test{
+			benchmark("BenchmarkOne-8", "8", "200"),
+			benchmark("BenchmarkOne-16", "16", "200"),
+			benchmark("BenchmarkOld-4", "4", "200"),
+		},
+	}}}
+
+	compareBenchmarks(&report, baseline, DefaultBenchmarkThresholds)
+	var got []gtr.Result
+	for _, test := range report.Packages[0].Tests {
+		got = append(got, test.Result)
+	}
+	want := []gtr.Result{gtr.Pass, gtr.Fail, gtr.Fail}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("compareBenchmarks incorrect results, diff (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,92 @@
+package gojunitreport
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jstemmer/go-junit-report/v2/cobertura"
+	"github.com/jstemmer/go-junit-report/v2/gtr"
+	"github.com/jstemmer/go-junit-report/v2/parser/coverprofile"
+)
+
+// readCoverProfiles reads and merges the coverage profiles with the given
+// names.
+func readCoverProfiles(names []string) (*gtr.Coverage, error) {
+	var coverage gtr.Coverage
+	for _, name := range names {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		profile, err := coverprofile.Parse(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if err := coverage.Merge(profile); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return &coverage, nil
+}
+
+// WriteCobertura writes coverage to w as a Cobertura XML report. The root of
+// the Go module in moduleRoot, or of the module containing the working
+// directory if moduleRoot is empty, is used as the source directory of the
+// files in that module and their filenames are made relative to it. The
+// source directory is written relative to the working directory, so that the
+// report does not depend on where the module is checked out.
+func WriteCobertura(w io.Writer, coverage gtr.Coverage, moduleRoot string, timestamp time.Time) error {
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	dir, modulePath, err := coberturaSource(moduleRoot, wd)
+	if err != nil {
+		return err
+	}
+
+	c := cobertura.CreateFromCoverage(coverage,
+		cobertura.SetTimestamp(timestamp),
+		cobertura.SetSource(dir, modulePath))
+	return c.WriteXML(w)
+}
+
+// coberturaSource returns the source directory, relative to wd, and the path
+// of the Go module in moduleRoot. If moduleRoot is empty, the module
+// containing wd is used.
+func coberturaSource(moduleRoot, wd string) (dir, modulePath string, err error) {
+	if moduleRoot == "" {
+		if moduleRoot, err = findModuleRoot(wd); err != nil {
+			return "", "", err
+		}
+	} else if !filepath.IsAbs(moduleRoot) {
+		moduleRoot = filepath.Join(wd, moduleRoot)
+	}
+
+	modulePath, err = readModulePath(filepath.Join(moduleRoot, "go.mod"))
+	if err != nil {
+		return "", "", err
+	}
+	dir, err = filepath.Rel(wd, moduleRoot)
+	if err != nil {
+		return "", "", err
+	}
+	return filepath.ToSlash(dir), modulePath, nil
+}
+
+// findModuleRoot returns the directory containing the go.mod file of the Go
+// module that dir belongs to.
+func findModuleRoot(dir string) (string, error) {
+	for d := filepath.Clean(dir); ; d = filepath.Dir(d) {
+		if _, err := os.Stat(filepath.Join(d, "go.mod")); err == nil {
+			return d, nil
+		}
+		if filepath.Dir(d) == d {
+			return "", fmt.Errorf("no go.mod found in %s or any parent directory, use -module-root to set the module root", dir)
+		}
+	}
+}
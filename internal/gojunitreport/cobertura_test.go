@@ -0,0 +1,49 @@
+package gojunitreport
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCoberturaSource(t *testing.T) {
+	wd, err := filepath.Abs(testDataDir + "src/subtests")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		moduleRoot string
+		wantDir    string
+	}{
+		{"", ".."},
+		{"..", ".."},
+		{filepath.Dir(wd), ".."},
+	}
+	for _, test := range tests {
+		dir, modulePath, err := coberturaSource(test.moduleRoot, wd)
+		if err != nil {
+			t.Errorf("coberturaSource(%q) failed: %v", test.moduleRoot, err)
+			continue
+		}
+		if dir != test.wantDir || modulePath != "package" {
+			t.Errorf("coberturaSource(%q) = %q, %q, want %q, %q", test.moduleRoot, dir, modulePath, test.wantDir, "package")
+		}
+	}
+}
+
+func TestCoberturaSourceNoModule(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-junit-report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if _, err := findModuleRoot(dir); err == nil {
+		t.Skipf("%s is inside a Go module", dir)
+	}
+	if _, _, err := coberturaSource("", dir); err == nil {
+		t.Errorf("coberturaSource did not return an error outside of a Go module")
+	}
+}
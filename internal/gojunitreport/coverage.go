@@ -0,0 +1,209 @@
+package gojunitreport
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/jstemmer/go-junit-report/v2/gtr"
+)
+
+// coverageTestName is the name of the testcases added by AddCoverageFailures.
+const coverageTestName = "Coverage"
+
+// CoverageThresholds contains the minimum statement coverage, in percent, of
+// the packages in a report. Thresholds that are 0 are not enforced.
+type CoverageThresholds struct {
+	// Package is the minimum coverage of each package.
+	Package float64
+
+	// Total is the minimum coverage of all packages. If the report contains
+	// coverage profiles, the total is the percentage of all statements that
+	// were covered. Otherwise the number of statements in each package is
+	// unknown, and all packages contribute equally to the average.
+	Total float64
+
+	// Overrides contains the minimum coverage of specific packages, which
+	// takes precedence over Package. A key ending in "/..." matches a package
+	// and all of its subpackages. If multiple keys match a package, an exact
+	// match is used, or otherwise the longest matching pattern.
+	Overrides map[string]float64
+}
+
+// CoverageShortfall describes a package, or the total when Package is empty,
+// whose coverage is below its threshold. NoData is true for a package with
+// tests for which no coverage was reported.
+type CoverageShortfall struct {
+	Package   string
+	Coverage  float64
+	Threshold float64
+	NoData    bool
+}
+
+func (s CoverageShortfall) String() string {
+	name := s.Package
+	if name == "" {
+		name = "total"
+	}
+	if s.NoData {
+		return fmt.Sprintf("%s: no coverage data, the minimum is %.1f%%", name, s.Threshold)
+	}
+	return fmt.Sprintf("%s: coverage %.1f%% is below the minimum of %.1f%%", name, s.Coverage, s.Threshold)
+}
+
+// ReadCoverageThresholds reads per-package coverage thresholds in JSON format
+// from r. The input should contain an object that maps package import paths,
+// or patterns ending in "/...", to their minimum coverage in percent.
+func ReadCoverageThresholds(r io.Reader) (map[string]float64, error) {
+	var overrides map[string]float64
+	if err := json.NewDecoder(r).Decode(&overrides); err != nil {
+		return nil, err
+	}
+	for pattern, threshold := range overrides {
+		if threshold < 0 || threshold > 100 {
+			return nil, fmt.Errorf("invalid coverage threshold for %s: %v", pattern, threshold)
+		}
+	}
+	return overrides, nil
+}
+
+// enabled returns true if any thresholds are set.
+func (t CoverageThresholds) enabled() bool {
+	return t.Package > 0 || t.Total > 0 || len(t.Overrides) > 0
+}
+
+// threshold returns the minimum coverage of the package with the given name.
+func (t CoverageThresholds) threshold(pkg string) float64 {
+	if threshold, ok := t.Overrides[pkg]; ok {
+		return threshold
+	}
+	threshold, matched := t.Package, ""
+	for pattern, value := range t.Overrides {
+		prefix := strings.TrimSuffix(pattern, "/...")
+		if prefix == pattern || (pkg != prefix && !strings.HasPrefix(pkg, prefix+"/")) {
+			continue
+		}
+		if len(prefix) > len(matched) {
+			threshold, matched = value, prefix
+		}
+	}
+	return threshold
+}
+
+// Check returns the packages in report whose coverage is below their
+// threshold, followed by the total if it is below the Total threshold. The
+// coverage of a package that did not report its coverage percentage is taken
+// from the coverage profiles in the report, if any. A package that reported a
+// coverage of 0% is included like any other package. Packages with tests but
+// without any coverage data are returned as a shortfall with NoData set, and
+// are not included in the total. Packages without tests and without coverage
+// are ignored.
+func (t CoverageThresholds) Check(report gtr.Report) []CoverageShortfall {
+	if !t.enabled() {
+		return nil
+	}
+
+	var shortfalls []CoverageShortfall
+	var sum float64
+	var count int
+	for _, pkg := range report.Packages {
+		coverage := pkg.Coverage
+		if !pkg.HasCoverage && coverage == 0 {
+			if covered, total := coveredStatements(report.Coverage, pkg.Name); total > 0 {
+				coverage = percent(covered, total)
+			} else if len(pkg.Tests) == 0 {
+				continue
+			} else {
+				if threshold := t.threshold(pkg.Name); threshold > 0 {
+					shortfalls = append(shortfalls, CoverageShortfall{Package: pkg.Name, Threshold: threshold, NoData: true})
+				}
+				continue
+			}
+		}
+		sum += coverage
+		count++
+		if threshold := t.threshold(pkg.Name); coverage < threshold {
+			shortfalls = append(shortfalls, CoverageShortfall{Package: pkg.Name, Coverage: coverage, Threshold: threshold})
+		}
+	}
+
+	if t.Total > 0 {
+		var total float64
+		if covered, statements := coveredStatements(report.Coverage, ""); statements > 0 {
+			total = percent(covered, statements)
+		} else if count > 0 {
+			total = sum / float64(count)
+		} else {
+			return shortfalls
+		}
+		if total < t.Total {
+			shortfalls = append(shortfalls, CoverageShortfall{Coverage: total, Threshold: t.Total})
+		}
+	}
+	return shortfalls
+}
+
+// coveredStatements returns the number of statements in coverage that belong
+// to the package with the given import path, or to any package if pkg is
+// empty, and the number of those statements that were covered.
+func coveredStatements(coverage *gtr.Coverage, pkg string) (covered, total int) {
+	if coverage == nil {
+		return 0, 0
+	}
+	for _, f := range coverage.Files {
+		if pkg != "" && path.Dir(f.Name) != pkg {
+			continue
+		}
+		for _, b := range f.Blocks {
+			total += b.Statements
+			if b.Count > 0 {
+				covered += b.Statements
+			}
+		}
+	}
+	return covered, total
+}
+
+// percent returns n as a percentage of total.
+func percent(n, total int) float64 {
+	return 100 * float64(n) / float64(total)
+}
+
+// AddCoverageFailures adds a failed "Coverage" testcase to each package in
+// report that has a coverage shortfall. A shortfall of the total coverage is
+// added to a separate package named "coverage".
+func AddCoverageFailures(report *gtr.Report, shortfalls []CoverageShortfall) {
+	for _, s := range shortfalls {
+		var pkg *gtr.Package
+		for i := range report.Packages {
+			if s.Package != "" && report.Packages[i].Name == s.Package {
+				pkg = &report.Packages[i]
+				break
+			}
+		}
+		if pkg == nil {
+			report.Packages = append(report.Packages, gtr.Package{Name: "coverage"})
+			pkg = &report.Packages[len(report.Packages)-1]
+		}
+
+		id := 0
+		for _, test := range pkg.Tests {
+			if test.ID > id {
+				id = test.ID
+			}
+		}
+		test := gtr.NewTest(id+1, coverageTestName)
+		test.Result = gtr.Fail
+		test.Failure = gtr.Failure{Type: "coverage", Message: s.String()}
+		test.Output = []string{s.String()}
+		pkg.Tests = append(pkg.Tests, test)
+	}
+}
+
+// isCoverageTest returns true if test is a testcase added by
+// AddCoverageFailures rather than an actual test.
+func isCoverageTest(test gtr.Test) bool {
+	return test.Name == coverageTestName && test.Failure.Type == "coverage"
+}
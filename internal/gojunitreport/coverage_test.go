@@ -0,0 +1,146 @@
+package gojunitreport
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jstemmer/go-junit-report/v2/gtr"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func coverageReport() gtr.Report {
+	pkg := func(name string, coverage float64) gtr.Package {
+		return gtr.Package{Name: name, Coverage: coverage, HasCoverage: true, Tests: []gtr.Test{{ID: 1, Name: "TestOne", Result: gtr.Pass}}}
+	}
+	nodata := pkg("example.com/nodata", 0)
+	nodata.HasCoverage = false
+	return gtr.Report{
+		Packages: []gtr.Package{
+			pkg("example.com/a", 90),
+			pkg("example.com/b", 50),
+			pkg("example.com/legacy", 10),
+			pkg("example.com/legacy/sub", 30),
+			pkg("example.com/zero", 0),
+			nodata,
+			{Name: "example.com/notests"},
+		},
+	}
+}
+
+func TestCoverageThresholds(t *testing.T) {
+	overrides, err := ReadCoverageThresholds(strings.NewReader(`{"example.com/legacy/...": 20, "example.com/legacy": 5}`))
+	if err != nil {
+		t.Fatalf("ReadCoverageThresholds failed: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		thresholds CoverageThresholds
+		want       []CoverageShortfall
+	}{
+		{"none", CoverageThresholds{}, nil},
+		{"package", CoverageThresholds{Package: 40}, []CoverageShortfall{
+			{Package: "example.com/legacy", Coverage: 10, Threshold: 40},
+			{Package: "example.com/legacy/sub", Coverage: 30, Threshold: 40},
+			{Package: "example.com/zero", Coverage: 0, Threshold: 40},
+			{Package: "example.com/nodata", Threshold: 40, NoData: true},
+		}},
+		{"overrides", CoverageThresholds{Package: 60, Overrides: overrides}, []CoverageShortfall{
+			{Package: "example.com/b", Coverage: 50, Threshold: 60},
+			{Package: "example.com/zero", Coverage: 0, Threshold: 60},
+			{Package: "example.com/nodata", Threshold: 60, NoData: true},
+		}},
+		{"total", CoverageThresholds{Total: 50}, []CoverageShortfall{
+			{Coverage: 36, Threshold: 50},
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.thresholds.Check(coverageReport())
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Check incorrect, diff (-want, +got):\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestCoverageThresholdsProfile(t *testing.T) {
+	report := coverageReport()
+	report.Coverage = &gtr.Coverage{
+		Mode: "set",
+		Files: []gtr.CoverageFile{
+			{Name: "example.com/a/a.go", Blocks: []gtr.CoverageBlock{
+				{StartLine: 1, EndLine: 2, Statements: 90, Count: 1},
+				{StartLine: 3, EndLine: 4, Statements: 10, Count: 0},
+			}},
+			{Name: "example.com/nodata/nodata.go", Blocks: []gtr.CoverageBlock{
+				{StartLine: 1, EndLine: 2, Statements: 1, Count: 1},
+				{StartLine: 3, EndLine: 4, Statements: 3, Count: 0},
+			}},
+		},
+	}
+
+	want := []CoverageShortfall{
+		{Package: "example.com/legacy", Coverage: 10, Threshold: 30},
+		{Package: "example.com/zero", Coverage: 0, Threshold: 30},
+		{Package: "example.com/nodata", Coverage: 25, Threshold: 30},
+		{Coverage: 87.5, Threshold: 90},
+	}
+	got := CoverageThresholds{Package: 30, Total: 90}.Check(report)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Check incorrect, diff (-want, +got):\n%s\n", diff)
+	}
+}
+
+func TestCoverageThresholdsZero(t *testing.T) {
+	report := gtr.Report{
+		Packages: []gtr.Package{
+			{Name: "example.com/a", Coverage: 90, HasCoverage: true},
+			{Name: "example.com/b", Coverage: 0, HasCoverage: true},
+		},
+	}
+
+	want := []CoverageShortfall{
+		{Coverage: 45, Threshold: 50},
+	}
+	got := CoverageThresholds{Total: 50}.Check(report)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Check incorrect, diff (-want, +got):\n%s\n", diff)
+	}
+}
+
+func TestReadCoverageThresholdsError(t *testing.T) {
+	if _, err := ReadCoverageThresholds(strings.NewReader(`{"example.com/a": 101}`)); err == nil {
+		t.Errorf("ReadCoverageThresholds did not return an error for an invalid threshold")
+	}
+}
+
+func TestAddCoverageFailures(t *testing.T) {
+	report := coverageReport()
+	AddCoverageFailures(&report, CoverageThresholds{Package: 40, Total: 50}.Check(report))
+
+	if report.IsSuccessful() {
+		t.Errorf("report with coverage failures is successful")
+	}
+
+	got := make(map[string]string)
+	for _, pkg := range report.Packages {
+		for _, test := range pkg.Tests {
+			if test.Name == "Coverage" {
+				got[pkg.Name] = test.Failure.Message
+			}
+		}
+	}
+	want := map[string]string{
+		"example.com/legacy":     "example.com/legacy: coverage 10.0% is below the minimum of 40.0%",
+		"example.com/legacy/sub": "example.com/legacy/sub: coverage 30.0% is below the minimum of 40.0%",
+		"example.com/zero":       "example.com/zero: coverage 0.0% is below the minimum of 40.0%",
+		"example.com/nodata":     "example.com/nodata: no coverage data, the minimum is 40.0%",
+		"coverage":               "total: coverage 36.0% is below the minimum of 50.0%",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("AddCoverageFailures incorrect, diff (-want, +got):\n%s\n", diff)
+	}
+}
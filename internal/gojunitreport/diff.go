@@ -0,0 +1,218 @@
+package gojunitreport
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jstemmer/go-junit-report/v2/gtr"
+)
+
+// DiffStatus describes how a test changed between two reports.
+type DiffStatus string
+
+const (
+	DiffNewlyFailing DiffStatus = "newly-failing" // failed, but did not fail before
+	DiffNewlyPassing DiffStatus = "newly-passing" // passed, but failed before
+	DiffStillFailing DiffStatus = "still-failing" // failed in both reports
+	DiffAdded        DiffStatus = "added"         // only exists in the new report
+	DiffRemoved      DiffStatus = "removed"       // only exists in the old report
+	DiffNewlySkipped DiffStatus = "newly-skipped" // skipped, but was not skipped before
+	DiffSlower       DiffStatus = "slower"        // passed, but took significantly longer
+)
+
+// diffStatuses contains all statuses in the order in which they are reported.
+var diffStatuses = []DiffStatus{
+	DiffNewlyFailing,
+	DiffNewlyPassing,
+	DiffStillFailing,
+	DiffAdded,
+	DiffRemoved,
+	DiffNewlySkipped,
+	DiffSlower,
+}
+
+// ParseDiffStatus returns a DiffStatus for the given string.
+func ParseDiffStatus(in string) (DiffStatus, error) {
+	for _, status := range diffStatuses {
+		if in == string(status) {
+			return status, nil
+		}
+	}
+	return "", fmt.Errorf("unknown diff status: %v", in)
+}
+
+// SlowerThreshold determines when a test is considered to be significantly
+// slower. A test is slower if its duration increased by more than Relative,
+// e.g. 0.5 for 50%, and by at least Min. A negative Relative threshold
+// disables the detection of slower tests.
+type SlowerThreshold struct {
+	Relative float64
+	Min      time.Duration
+}
+
+// DefaultSlowerThreshold contains the default threshold for slower tests.
+var DefaultSlowerThreshold = SlowerThreshold{Relative: 0.5, Min: 100 * time.Millisecond}
+
+// TestDiff describes the change of a single test between two reports.
+type TestDiff struct {
+	Package string     `json:"package"`
+	Name    string     `json:"name"`
+	Status  DiffStatus `json:"status"`
+
+	OldResult   string        `json:"old_result,omitempty"`
+	NewResult   string        `json:"new_result,omitempty"`
+	OldDuration time.Duration `json:"old_duration,omitempty"`
+	NewDuration time.Duration `json:"new_duration,omitempty"`
+}
+
+// DiffReports compares the tests in the old and new reports and returns the
+// tests that changed, ordered by status, package and name. Tests are matched
+// by package and test name. Tests that did not change are not returned. If a
+// test appears more than once in a report, it is considered failed if any of
+// its runs failed.
+func DiffReports(oldReport, newReport gtr.Report, slower SlowerThreshold) []TestDiff {
+	oldTests, newTests := diffTests(oldReport), diffTests(newReport)
+
+	var diffs []TestDiff
+	for k, n := range newTests {
+		d := TestDiff{Package: k.pkg, Name: k.name, NewResult: n.Result.String(), NewDuration: n.Duration}
+		o, ok := oldTests[k]
+		if !ok {
+			d.Status = DiffAdded
+			diffs = append(diffs, d)
+			continue
+		}
+		d.OldResult, d.OldDuration = o.Result.String(), o.Duration
+
+		switch {
+		case isFailure(n.Result) && isFailure(o.Result):
+			d.Status = DiffStillFailing
+		case isFailure(n.Result):
+			d.Status = DiffNewlyFailing
+		case isFailure(o.Result) && n.Result == gtr.Pass:
+			d.Status = DiffNewlyPassing
+		case n.Result == gtr.Skip && o.Result != gtr.Skip:
+			d.Status = DiffNewlySkipped
+		case n.Result == gtr.Pass && o.Result == gtr.Pass && slower.exceeded(o.Duration, n.Duration):
+			d.Status = DiffSlower
+		default:
+			continue
+		}
+		diffs = append(diffs, d)
+	}
+	for k, o := range oldTests {
+		if _, ok := newTests[k]; !ok {
+			diffs = append(diffs, TestDiff{Package: k.pkg, Name: k.name, Status: DiffRemoved, OldResult: o.Result.String(), OldDuration: o.Duration})
+		}
+	}
+
+	order := make(map[DiffStatus]int)
+	for i, status := range diffStatuses {
+		order[status] = i
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		if diffs[i].Status != diffs[j].Status {
+			return order[diffs[i].Status] < order[diffs[j].Status]
+		}
+		if diffs[i].Package != diffs[j].Package {
+			return diffs[i].Package < diffs[j].Package
+		}
+		return diffs[i].Name < diffs[j].Name
+	})
+	return diffs
+}
+
+type testKey struct{ pkg, name string }
+
+// diffTests returns the tests in report by package and name. Tests that
+// appear multiple times are combined into a single test. The testcases added
+// for packages below their minimum coverage are not tests and are skipped.
+func diffTests(report gtr.Report) map[testKey]gtr.Test {
+	tests := make(map[testKey]gtr.Test)
+	for _, pkg := range report.Packages {
+		for _, test := range pkg.Tests {
+			if isCoverageTest(test) {
+				continue
+			}
+			k := testKey{pkg.Name, test.Name}
+			if prev, ok := tests[k]; ok {
+				if isFailure(prev.Result) {
+					test.Result = prev.Result
+				}
+				if prev.Duration > test.Duration {
+					test.Duration = prev.Duration
+				}
+			}
+			tests[k] = test
+		}
+	}
+	return tests
+}
+
+// isFailure returns true if result is a failure, or if the test did not
+// finish.
+func isFailure(result gtr.Result) bool {
+	return result == gtr.Fail || result == gtr.Unknown
+}
+
+// exceeded returns true if the increase from prev to cur exceeds threshold t.
+func (t SlowerThreshold) exceeded(prev, cur time.Duration) bool {
+	if t.Relative < 0 || cur-prev < t.Min {
+		return false
+	}
+	return float64(cur) > float64(prev)*(1+t.Relative)
+}
+
+// WriteDiffJSON writes the test diffs to w as a JSON array.
+func WriteDiffJSON(w io.Writer, diffs []TestDiff) error {
+	if diffs == nil {
+		diffs = []TestDiff{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(diffs)
+}
+
+// WriteDiffText writes the test diffs to w in a human readable format, with a
+// section for each status followed by a summary.
+func WriteDiffText(w io.Writer, diffs []TestDiff) error {
+	counts := make(map[DiffStatus]int)
+	for _, d := range diffs {
+		counts[d.Status]++
+	}
+
+	var b strings.Builder
+	for _, status := range diffStatuses {
+		if counts[status] == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "%s (%d):\n", status, counts[status])
+		for _, d := range diffs {
+			if d.Status != status {
+				continue
+			}
+			fmt.Fprintf(&b, "  %s %s", d.Package, d.Name)
+			if status == DiffSlower {
+				fmt.Fprintf(&b, " (%s -> %s", d.OldDuration, d.NewDuration)
+				if d.OldDuration > 0 {
+					fmt.Fprintf(&b, ", +%.0f%%", (float64(d.NewDuration)/float64(d.OldDuration)-1)*100)
+				}
+				b.WriteString(")")
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+
+	var summary []string
+	for _, status := range diffStatuses {
+		summary = append(summary, fmt.Sprintf("%d %s", counts[status], status))
+	}
+	fmt.Fprintf(&b, "%s\n", strings.Join(summary, ", "))
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,84 @@
+package gojunitreport
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/jstemmer/go-junit-report/v2/gtr"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func diffReport(tests ...gtr.Test) gtr.Report {
+	pkg := gtr.Package{Name: "package/name"}
+	for i, test := range tests {
+		test.ID = i + 1
+		pkg.Tests = append(pkg.Tests, test)
+	}
+	return gtr.Report{Packages: []gtr.Package{pkg}}
+}
+
+func TestDiffReports(t *testing.T) {
+	oldReport := diffReport(
+		gtr.Test{Name: "TestBroken", Result: gtr.Pass},
+		gtr.Test{Name: "TestFixed", Result: gtr.Fail},
+		gtr.Test{Name: "TestFailing", Result: gtr.Fail},
+		gtr.Test{Name: "TestRemoved", Result: gtr.Pass},
+		gtr.Test{Name: "TestSkipped", Result: gtr.Pass},
+		gtr.Test{Name: "TestSlow", Result: gtr.Pass, Duration: 100 * time.Millisecond},
+		gtr.Test{Name: "TestSlightlySlower", Result: gtr.Pass, Duration: 100 * time.Millisecond},
+		gtr.Test{Name: "TestFast", Result: gtr.Pass, Duration: time.Millisecond},
+		gtr.Test{Name: "TestUnchanged", Result: gtr.Pass},
+	)
+	newReport := diffReport(
+		gtr.Test{Name: "TestBroken", Result: gtr.Fail},
+		gtr.Test{Name: "TestFixed", Result: gtr.Pass},
+		gtr.Test{Name: "TestFailing", Result: gtr.Unknown},
+		gtr.Test{Name: "TestAdded", Result: gtr.Pass},
+		gtr.Test{Name: "TestSkipped", Result: gtr.Skip},
+		gtr.Test{Name: "TestSlow", Result: gtr.Pass, Duration: time.Second},
+		gtr.Test{Name: "TestSlightlySlower", Result: gtr.Pass, Duration: 120 * time.Millisecond},
+		gtr.Test{Name: "TestFast", Result: gtr.Pass, Duration: 10 * time.Millisecond},
+		gtr.Test{Name: "TestUnchanged", Result: gtr.Pass},
+		gtr.Test{Name: "TestUnchanged", Result: gtr.Pass},
+	)
+	AddCoverageFailures(&newReport, []CoverageShortfall{{Package: "package/name", Coverage: 10, Threshold: 50}})
+
+	want := []TestDiff{
+		{Package: "package/name", Name: "TestBroken", Status: DiffNewlyFailing, OldResult: "PASS", NewResult: "FAIL"},
+		{Package: "package/name", Name: "TestFixed", Status: DiffNewlyPassing, OldResult: "FAIL", NewResult: "PASS"},
+		{Package: "package/name", Name: "TestFailing", Status: DiffStillFailing, OldResult: "FAIL", NewResult: "UNKNOWN"},
+		{Package: "package/name", Name: "TestAdded", Status: DiffAdded, NewResult: "PASS"},
+		{Package: "package/name", Name: "TestRemoved", Status: DiffRemoved, OldResult: "PASS"},
+		{Package: "package/name", Name: "TestSkipped", Status: DiffNewlySkipped, OldResult: "PASS", NewResult: "SKIP"},
+		{Package: "package/name", Name: "TestSlow", Status: DiffSlower, OldResult: "PASS", NewResult: "PASS", OldDuration: 100 * time.Millisecond, NewDuration: time.Second},
+	}
+
+	got := DiffReports(oldReport, newReport, DefaultSlowerThreshold)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("DiffReports incorrect, diff (-want, +got):\n%s\n", diff)
+	}
+
+	for _, d := range DiffReports(oldReport, newReport, SlowerThreshold{Relative: -1}) {
+		if d.Status == DiffSlower {
+			t.Errorf("DiffReports with disabled slower threshold returned slower test %s", d.Name)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := WriteDiffText(&buf, got[5:]); err != nil {
+		t.Fatalf("WriteDiffText failed: %v", err)
+	}
+	wantText := `newly-skipped (1):
+  package/name TestSkipped
+
+slower (1):
+  package/name TestSlow (100ms -> 1s, +900%)
+
+0 newly-failing, 0 newly-passing, 0 still-failing, 0 added, 0 removed, 1 newly-skipped, 1 slower
+`
+	if diff := cmp.Diff(wantText, buf.String()); diff != "" {
+		t.Errorf("WriteDiffText incorrect, diff (-want, +got):\n%s\n", diff)
+	}
+}
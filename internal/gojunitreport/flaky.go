@@ -0,0 +1,205 @@
+package gojunitreport
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jstemmer/go-junit-report/v2/gtr"
+)
+
+// FlakyTest contains the results of a single test across multiple reports.
+type FlakyTest struct {
+	Package string `json:"package"`
+	Name    string `json:"name"`
+
+	Runs     int `json:"runs"`     // number of attempts that passed or failed
+	Passes   int `json:"passes"`   // number of attempts that passed
+	Failures int `json:"failures"` // number of attempts that failed
+	Flips    int `json:"flips"`    // number of times the result changed
+
+	// FlipRate is the fraction of consecutive runs with a different result,
+	// FailureRate is the fraction of runs that failed.
+	FlipRate    float64 `json:"flip_rate"`
+	FailureRate float64 `json:"failure_rate"`
+
+	// LastFailure is the timestamp of the most recent report in which this
+	// test failed, if known.
+	LastFailure *time.Time `json:"last_failure,omitempty"`
+}
+
+// ReadReportDir reads all JUnit XML and gtr JSON reports in dir and returns
+// them ordered by the time they were created, oldest first. The creation time
+// is taken from the package timestamps in the report, or from the file
+// modification time if the report does not contain timestamps.
+func ReadReportDir(dir string) ([]gtr.Report, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	type timedReport struct {
+		report gtr.Report
+		time   time.Time
+	}
+
+	var reports []timedReport
+	for _, file := range files {
+		ext := filepath.Ext(file.Name())
+		if file.IsDir() || (ext != ".xml" && ext != ".json") {
+			continue
+		}
+
+		f, err := os.Open(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		report, err := ReadReport(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %w", file.Name(), err)
+		}
+
+		t := reportTime(report)
+		if t.IsZero() {
+			t = file.ModTime()
+			for i := range report.Packages {
+				report.Packages[i].Timestamp = t
+			}
+		}
+		reports = append(reports, timedReport{report, t})
+	}
+
+	sort.SliceStable(reports, func(i, j int) bool {
+		return reports[i].time.Before(reports[j].time)
+	})
+
+	result := make([]gtr.Report, len(reports))
+	for i, r := range reports {
+		result[i] = r.report
+	}
+	return result, nil
+}
+
+// reportTime returns the earliest package timestamp in report.
+func reportTime(report gtr.Report) time.Time {
+	var t time.Time
+	for _, pkg := range report.Packages {
+		if !pkg.Timestamp.IsZero() && (t.IsZero() || pkg.Timestamp.Before(t)) {
+			t = pkg.Timestamp
+		}
+	}
+	return t
+}
+
+// FindFlakyTests returns the tests in reports that both passed and failed at
+// least once, ranked by their flip rate and failure rate. Reports must be
+// ordered oldest first. Tests that ran fewer than minRuns times are ignored.
+// Tests that were run multiple times within a report, see
+// gtr.Package.GroupAttempts, contribute each of their attempts.
+func FindFlakyTests(reports []gtr.Report, minRuns int) []FlakyTest {
+	type key struct{ pkg, name string }
+	stats := make(map[key]*FlakyTest)
+	last := make(map[key]gtr.Result)
+
+	for _, report := range reports {
+		for _, pkg := range report.Packages {
+			for _, test := range pkg.Tests {
+				k := key{pkg.Name, test.Name}
+				s, ok := stats[k]
+				if !ok {
+					s = &FlakyTest{Package: pkg.Name, Name: test.Name}
+					stats[k] = s
+				}
+
+				attempts := test.Attempts
+				if len(attempts) == 0 {
+					attempts = []gtr.Test{test}
+				}
+				for _, attempt := range attempts {
+					if attempt.Result != gtr.Pass && attempt.Result != gtr.Fail {
+						continue
+					}
+					s.Runs++
+					if attempt.Result == gtr.Pass {
+						s.Passes++
+					} else {
+						s.Failures++
+						if !pkg.Timestamp.IsZero() {
+							ts := pkg.Timestamp
+							s.LastFailure = &ts
+						}
+					}
+					if prev, ok := last[k]; ok && prev != attempt.Result {
+						s.Flips++
+					}
+					last[k] = attempt.Result
+				}
+			}
+		}
+	}
+
+	var flaky []FlakyTest
+	for _, s := range stats {
+		if s.Passes == 0 || s.Failures == 0 || s.Runs < minRuns {
+			continue
+		}
+		s.FailureRate = float64(s.Failures) / float64(s.Runs)
+		s.FlipRate = float64(s.Flips) / float64(s.Runs-1)
+		flaky = append(flaky, *s)
+	}
+
+	sort.Slice(flaky, func(i, j int) bool {
+		if flaky[i].FlipRate != flaky[j].FlipRate {
+			return flaky[i].FlipRate > flaky[j].FlipRate
+		}
+		if flaky[i].FailureRate != flaky[j].FailureRate {
+			return flaky[i].FailureRate > flaky[j].FailureRate
+		}
+		if flaky[i].Package != flaky[j].Package {
+			return flaky[i].Package < flaky[j].Package
+		}
+		return flaky[i].Name < flaky[j].Name
+	})
+	return flaky
+}
+
+// WriteFlakyJSON writes the flaky tests to w as a JSON array.
+func WriteFlakyJSON(w io.Writer, tests []FlakyTest) error {
+	if tests == nil {
+		tests = []FlakyTest{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(tests)
+}
+
+// WriteFlakyMarkdown writes the flaky tests to w as a Markdown table.
+func WriteFlakyMarkdown(w io.Writer, tests []FlakyTest) error {
+	var b strings.Builder
+	b.WriteString("| Rank | Package | Test | Runs | Failures | Failure rate | Flip rate | Last failure |\n")
+	b.WriteString("| ---: | ------- | ---- | ---: | -------: | -----------: | --------: | ------------ |\n")
+	for i, t := range tests {
+		lastFailure := ""
+		if t.LastFailure != nil {
+			lastFailure = t.LastFailure.Format(time.RFC3339)
+		}
+		fmt.Fprintf(&b, "| %d | %s | %s | %d | %d | %.1f%% | %.1f%% | %s |\n",
+			i+1, escapeMarkdown(t.Package), escapeMarkdown(t.Name), t.Runs, t.Failures,
+			t.FailureRate*100, t.FlipRate*100, lastFailure)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// escapeMarkdown escapes characters that have a special meaning in Markdown
+// tables.
+func escapeMarkdown(s string) string {
+	return strings.NewReplacer("|", `\|`, "_", `\_`, "*", `\*`).Replace(s)
+}
@@ -0,0 +1,117 @@
+package gojunitreport
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jstemmer/go-junit-report/v2/gtr"
+	"github.com/jstemmer/go-junit-report/v2/junit"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func flakyReport(ts time.Time, results map[string]gtr.Result) gtr.Report {
+	pkg := gtr.Package{Name: "package/name", Timestamp: ts}
+	for _, name := range []string{"TestFlaky", "TestPass", "TestFail", "TestRetry"} {
+		if result, ok := results[name]; ok {
+			test := gtr.NewTest(len(pkg.Tests)+1, name)
+			test.Result = result
+			pkg.Tests = append(pkg.Tests, test)
+		}
+	}
+	return gtr.Report{Packages: []gtr.Package{pkg}}
+}
+
+func TestFindFlakyTests(t *testing.T) {
+	t1 := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Hour)
+	t3 := t2.Add(time.Hour)
+
+	retried := flakyReport(t3, map[string]gtr.Result{"TestFlaky": gtr.Pass, "TestPass": gtr.Pass, "TestFail": gtr.Fail, "TestRetry": gtr.Fail})
+	retry := gtr.NewTest(5, "TestRetry")
+	retry.Result = gtr.Pass
+	retried.Packages[0].Tests = append(retried.Packages[0].Tests, retry)
+	retried.Packages[0].GroupAttempts()
+
+	reports := []gtr.Report{
+		flakyReport(t1, map[string]gtr.Result{"TestFlaky": gtr.Pass, "TestPass": gtr.Pass, "TestFail": gtr.Fail, "TestRetry": gtr.Pass}),
+		flakyReport(t2, map[string]gtr.Result{"TestFlaky": gtr.Fail, "TestPass": gtr.Pass, "TestFail": gtr.Fail, "TestRetry": gtr.Pass}),
+		retried,
+	}
+
+	want := []FlakyTest{
+		{Package: "package/name", Name: "TestFlaky", Runs: 3, Passes: 2, Failures: 1, Flips: 2, FlipRate: 1, FailureRate: 1.0 / 3, LastFailure: &t2},
+		{Package: "package/name", Name: "TestRetry", Runs: 4, Passes: 3, Failures: 1, Flips: 2, FlipRate: 2.0 / 3, FailureRate: 0.25, LastFailure: &t3},
+	}
+
+	got := FindFlakyTests(reports, 2)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("FindFlakyTests incorrect, diff (-want, +got):\n%s\n", diff)
+	}
+
+	if got := FindFlakyTests(reports, 4); len(got) != 1 || got[0].Name != "TestRetry" {
+		t.Errorf("FindFlakyTests with minRuns=4 returned %v, want only TestRetry", got)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteFlakyMarkdown(&buf, want[:1]); err != nil {
+		t.Fatalf("WriteFlakyMarkdown failed: %v", err)
+	}
+	wantMarkdown := `| Rank | Package | Test | Runs | Failures | Failure rate | Flip rate | Last failure |
+| ---: | ------- | ---- | ---: | -------: | -----------: | --------: | ------------ |
+| 1 | package/name | TestFlaky | 3 | 1 | 33.3% | 100.0% | 2022-01-01T01:00:00Z |
+`
+	if diff := cmp.Diff(wantMarkdown, buf.String()); diff != "" {
+		t.Errorf("WriteFlakyMarkdown incorrect, diff (-want, +got):\n%s\n", diff)
+	}
+}
+
+func TestReadReportDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-junit-report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	t1 := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Hour)
+
+	newer := flakyReport(t2, map[string]gtr.Result{"TestFlaky": gtr.Fail})
+	var buf bytes.Buffer
+	if err := newer.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "a.json"), buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	older := flakyReport(t1, map[string]gtr.Result{"TestFlaky": gtr.Pass})
+	buf.Reset()
+	suites := junit.CreateFromReport(older, "")
+	if err := suites.WriteXML(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "b.xml"), buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	reports, err := ReadReportDir(dir)
+	if err != nil {
+		t.Fatalf("ReadReportDir failed: %v", err)
+	}
+	if len(reports) != 2 {
+		t.Fatalf("ReadReportDir returned %d reports, want 2", len(reports))
+	}
+	for i, want := range []gtr.Result{gtr.Pass, gtr.Fail} {
+		if got := reports[i].Packages[0].Tests[0].Result; got != want {
+			t.Errorf("report %d: got result %v, want %v", i, got, want)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sort"
 	"time"
 
 	"github.com/jstemmer/go-junit-report/v2/gtr"
@@ -15,12 +16,14 @@ import (
 
 type parser interface {
 	Parse(r io.Reader) (gtr.Report, error)
+	ParseStreams(stdout, stderr io.Reader) (gtr.Report, error)
 	Events() []gotest.Event
 }
 
 // Config contains the go-junit-report command configuration.
 type Config struct {
 	Parser        string
+	OutputFormat  string // junit (default) or json
 	Hostname      string
 	PackageName   string
 	SkipXMLHeader bool
@@ -28,12 +31,82 @@ type Config struct {
 	Properties    map[string]string
 	TimestampFunc func() time.Time
 
+	// BenchmarkBaseline is an optional report containing previous benchmark
+	// results. Benchmarks that regressed compared to the baseline by more
+	// than the configured BenchmarkThresholds are marked as failed.
+	BenchmarkBaseline   *gtr.Report
+	BenchmarkThresholds BenchmarkThresholds
+
+	// ModuleRoot is the optional root directory of the Go module that was
+	// tested. If set, the source files and line numbers of tests are
+	// resolved from the _test.go files in this module.
+	ModuleRoot string
+
+	// GroupAttempts merges tests with the same name in a package into a
+	// single test with multiple attempts, e.g. when using `go test -count`.
+	GroupAttempts bool
+
+	// Quarantine contains known flaky tests, whose failures are reported as
+	// skipped tests.
+	Quarantine Quarantine
+
+	// CoverageThresholds contains the minimum coverage of packages. If
+	// CoverageTestcases is set, a failed testcase is added for each package,
+	// and for the total, that does not meet its threshold. Otherwise the
+	// thresholds are not checked and callers should use
+	// CoverageThresholds.Check on the returned report.
+	CoverageThresholds CoverageThresholds
+	CoverageTestcases  bool
+
+	// CoverProfiles contains the names of coverage profiles written by
+	// `go test -coverprofile`, which are merged and added to the report.
+	CoverProfiles []string
+
+	// OutputLimits optionally limits the size of the output in the report.
+	OutputLimits OutputLimits
+
+	// Redactor optionally replaces secrets in the test output.
+	Redactor *gotest.Redactor
+
+	// SpillOutput stores the captured test output in a temporary file in
+	// SpillDir while parsing, instead of keeping it in memory. The events
+	// created by the parser are not retained, unless PrintEvents is set. The
+	// Test and Package OutputLimits are applied to each package as soon as it
+	// has been parsed, so only the truncated output is kept in memory.
+	SpillOutput bool
+	SpillDir    string
+
+	// Kinds optionally restricts the report to tests of the given kinds.
+	// GroupByKind creates a separate testsuite for each kind of test.
+	Kinds       []gtr.Kind
+	GroupByKind bool
+
+	// SubtestFormat configures how subtests are represented in the JUnit
+	// report.
+	SubtestFormat junit.SubtestFormat
+
+	// ClassnameTemplate, NameTemplate and SuiteNameTemplate are optional
+	// text/template templates used to generate the testcase classnames,
+	// testcase names and testsuite names in the JUnit report. See
+	// junit.TemplateData for the available data.
+	ClassnameTemplate string
+	NameTemplate      string
+	SuiteNameTemplate string
+
 	// For debugging
 	PrintEvents bool
 }
 
 // Run runs the go-junit-report command and returns the generated report.
 func (c Config) Run(input io.Reader, output io.Writer) (*gtr.Report, error) {
+	return c.RunStreams(input, nil, output)
+}
+
+// RunStreams runs the go-junit-report command for test output that was
+// written to separate stdout and stderr streams, and returns the generated
+// report. Output that was written to stderr is reported separately. If stderr
+// is nil, RunStreams behaves like Run.
+func (c Config) RunStreams(stdout, stderr io.Reader, output io.Writer) (*gtr.Report, error) {
 	var p parser
 	switch c.Parser {
 	case "gotest":
@@ -44,7 +117,13 @@ func (c Config) Run(input io.Reader, output io.Writer) (*gtr.Report, error) {
 		return nil, fmt.Errorf("invalid parser: %s", c.Parser)
 	}
 
-	report, err := p.Parse(input)
+	var report gtr.Report
+	var err error
+	if stderr != nil {
+		report, err = p.ParseStreams(stdout, stderr)
+	} else {
+		report, err = p.Parse(stdout)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("error parsing input: %w", err)
 	}
@@ -58,20 +137,87 @@ func (c Config) Run(input io.Reader, output io.Writer) (*gtr.Report, error) {
 		}
 	}
 
+	var propNames []string
+	for k := range c.Properties {
+		propNames = append(propNames, k)
+	}
+	sort.Strings(propNames)
 	for i := range report.Packages {
-		for k, v := range c.Properties {
-			report.Packages[i].SetProperty(k, v)
+		for _, k := range propNames {
+			report.Packages[i].SetProperty(k, c.Properties[k])
+		}
+	}
+
+	if len(c.CoverProfiles) > 0 {
+		if report.Coverage, err = readCoverProfiles(c.CoverProfiles); err != nil {
+			return nil, fmt.Errorf("error reading coverage profile: %w", err)
+		}
+	}
+
+	if c.GroupAttempts {
+		for i := range report.Packages {
+			report.Packages[i].GroupAttempts()
+		}
+	}
+
+	if c.ModuleRoot != "" {
+		resolver, err := newSourceResolver(c.ModuleRoot)
+		if err != nil {
+			return nil, fmt.Errorf("error reading module: %w", err)
+		}
+		if err := resolver.Resolve(&report); err != nil {
+			return nil, fmt.Errorf("error resolving test sources: %w", err)
 		}
 	}
 
-	if err = c.writeJunitXML(output, report); err != nil {
+	if c.BenchmarkBaseline != nil {
+		compareBenchmarks(&report, *c.BenchmarkBaseline, c.BenchmarkThresholds)
+	}
+
+	if len(c.Quarantine) > 0 {
+		c.Quarantine.Apply(&report)
+	}
+
+	if c.CoverageTestcases {
+		AddCoverageFailures(&report, c.CoverageThresholds.Check(report))
+	}
+
+	c.OutputLimits.Apply(&report)
+
+	switch c.OutputFormat {
+	case "", "junit":
+		err = c.writeJunitXML(output, report)
+	case "json":
+		err = report.WriteJSON(output)
+	default:
+		err = fmt.Errorf("invalid output format: %s", c.OutputFormat)
+	}
+	if err != nil {
 		return nil, err
 	}
 	return &report, nil
 }
 
 func (c Config) writeJunitXML(w io.Writer, report gtr.Report) error {
-	testsuites := junit.CreateFromReport(report, c.Hostname)
+	var opts []junit.Option
+	if len(c.Kinds) > 0 {
+		opts = append(opts, junit.FilterKinds(c.Kinds...))
+	}
+	if c.GroupByKind {
+		opts = append(opts, junit.GroupByKind())
+	}
+	if c.SubtestFormat != junit.SubtestFormatDefault {
+		opts = append(opts, junit.SetSubtestFormat(c.SubtestFormat))
+	}
+	if c.ClassnameTemplate != "" || c.NameTemplate != "" || c.SuiteNameTemplate != "" {
+		templates, err := junit.ParseTemplates(c.ClassnameTemplate, c.NameTemplate, c.SuiteNameTemplate)
+		if err != nil {
+			return fmt.Errorf("invalid template: %w", err)
+		}
+		opts = append(opts, junit.SetTemplates(templates))
+	}
+
+	testsuites := junit.CreateFromReport(report, c.Hostname, opts...)
 	if !c.SkipXMLHeader {
 		_, err := fmt.Fprintf(w, xml.Header)
 		if err != nil {
@@ -82,9 +228,22 @@ func (c Config) writeJunitXML(w io.Writer, report gtr.Report) error {
 }
 
 func (c Config) gotestOptions() []gotest.Option {
-	return []gotest.Option{
+	opts := []gotest.Option{
 		gotest.PackageName(c.PackageName),
 		gotest.SetSubtestMode(c.SubtestMode),
 		gotest.TimestampFunc(c.TimestampFunc),
 	}
+	if c.Redactor != nil {
+		opts = append(opts, gotest.RedactOutput(c.Redactor))
+	}
+	if c.SpillOutput {
+		opts = append(opts, gotest.OutputDir(c.SpillDir))
+		if c.OutputLimits.enabled() {
+			opts = append(opts, gotest.PackageFunc(c.OutputLimits.ApplyPackage))
+		}
+		if !c.PrintEvents {
+			opts = append(opts, gotest.DiscardEvents())
+		}
+	}
+	return opts
 }
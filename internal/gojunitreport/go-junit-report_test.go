@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/jstemmer/go-junit-report/v2/junit"
 )
 
 const testDataDir = "../../testdata/"
@@ -25,6 +26,9 @@ var testConfigs = map[int]Config{
 	6:  {SkipXMLHeader: true},
 	7:  {PackageName: "test/package"},
 	39: {Properties: make(map[string]string)},
+	45: {GroupByKind: true},
+	46: {GroupAttempts: true},
+	47: {SubtestFormat: junit.NestedSuites},
 }
 
 func TestRun(t *testing.T) {
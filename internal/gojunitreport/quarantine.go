@@ -0,0 +1,160 @@
+package gojunitreport
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/jstemmer/go-junit-report/v2/gtr"
+)
+
+// QuarantineRule describes a known flaky test whose failures should not fail
+// the report.
+type QuarantineRule struct {
+	// Package is the import path of the package containing the test. If
+	// empty, the rule applies to tests in all packages.
+	Package string `json:"package"`
+
+	// Test is a slash separated list of regular expressions, which are
+	// matched against the corresponding parts of the test name in the same
+	// way as the `go test -run` flag. Subtests of a matching test match as
+	// well.
+	Test string `json:"test"`
+
+	Owner  string `json:"owner"`
+	Ticket string `json:"ticket"`
+
+	patterns []*regexp.Regexp
+}
+
+// Quarantine is a list of quarantined tests.
+type Quarantine []QuarantineRule
+
+// ReadQuarantine reads a list of quarantine rules in JSON format from r. The
+// input should contain an array of objects with the fields "package", "test",
+// "owner" and "ticket".
+func ReadQuarantine(r io.Reader) (Quarantine, error) {
+	var q Quarantine
+	if err := json.NewDecoder(r).Decode(&q); err != nil {
+		return nil, err
+	}
+	for i := range q {
+		if q[i].Test == "" {
+			return nil, fmt.Errorf("quarantine rule %d: missing test pattern", i+1)
+		}
+		for _, part := range strings.Split(q[i].Test, "/") {
+			re, err := regexp.Compile(part)
+			if err != nil {
+				return nil, fmt.Errorf("quarantine rule %d: %w", i+1, err)
+			}
+			q[i].patterns = append(q[i].patterns, re)
+		}
+	}
+	return q, nil
+}
+
+// Match returns true if the test with the given name in pkg matches this rule.
+func (r QuarantineRule) Match(pkg, name string) bool {
+	if r.Package != "" && r.Package != pkg {
+		return false
+	}
+	parts := strings.Split(name, "/")
+	if len(parts) < len(r.patterns) {
+		return false
+	}
+	for i, re := range r.patterns {
+		if !re.MatchString(parts[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply marks failed tests in report that match a quarantine rule as skipped.
+// Their output is kept and the owner and ticket of the rule are added as
+// properties. Failed tests whose failure was only caused by quarantined
+// subtests are marked as skipped as well.
+func (q Quarantine) Apply(report *gtr.Report) {
+	for i := range report.Packages {
+		pkg := &report.Packages[i]
+		for j := range pkg.Tests {
+			test := &pkg.Tests[j]
+			if test.Result != gtr.Fail {
+				continue
+			}
+			for _, rule := range q {
+				if rule.Match(pkg.Name, test.Name) {
+					quarantineTest(test, rule)
+					break
+				}
+			}
+		}
+		quarantineParents(pkg)
+	}
+}
+
+// quarantineParents marks failed tests as skipped if they did not report a
+// failure themselves and all of their failed subtests were quarantined.
+func quarantineParents(pkg *gtr.Package) {
+	for j := len(pkg.Tests) - 1; j >= 0; j-- {
+		parent := &pkg.Tests[j]
+		if parent.Result != gtr.Fail || parent.Failure != (gtr.Failure{}) {
+			continue
+		}
+
+		var rule *QuarantineRule
+		failed := false
+		for k := range pkg.Tests {
+			sub := pkg.Tests[k]
+			if !strings.HasPrefix(sub.Name, parent.Name+"/") {
+				continue
+			}
+			if sub.Result == gtr.Fail {
+				failed = true
+				break
+			}
+			if r, ok := quarantinedBy(sub); ok && rule == nil {
+				rule = &r
+			}
+		}
+		if !failed && rule != nil {
+			quarantineTest(parent, *rule)
+		}
+	}
+}
+
+func quarantineTest(test *gtr.Test, rule QuarantineRule) {
+	test.Result = gtr.Skip
+	test.AddProperty("quarantine.ticket", rule.Ticket)
+	test.AddProperty("quarantine.owner", rule.Owner)
+}
+
+// HasQuarantinedFailures returns true if report contains failed tests that
+// were marked as skipped by Quarantine.Apply.
+func HasQuarantinedFailures(report gtr.Report) bool {
+	for _, pkg := range report.Packages {
+		for _, test := range pkg.Tests {
+			if _, ok := quarantinedBy(test); ok && test.Result == gtr.Skip {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// quarantinedBy returns the quarantine rule that was applied to test, if any.
+func quarantinedBy(test gtr.Test) (QuarantineRule, bool) {
+	var rule QuarantineRule
+	var ok bool
+	for _, p := range test.Properties {
+		switch p.Name {
+		case "quarantine.ticket":
+			rule.Ticket, ok = p.Value, true
+		case "quarantine.owner":
+			rule.Owner = p.Value
+		}
+	}
+	return rule, ok
+}
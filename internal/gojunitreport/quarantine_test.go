@@ -0,0 +1,88 @@
+package gojunitreport
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jstemmer/go-junit-report/v2/gtr"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestQuarantineMatch(t *testing.T) {
+	q, err := ReadQuarantine(strings.NewReader(`[
+		{"package": "package/name", "test": "TestOne/^sub$", "owner": "team", "ticket": "BUG-1"},
+		{"test": "Flaky", "owner": "team", "ticket": "BUG-2"}
+	]`))
+	if err != nil {
+		t.Fatalf("ReadQuarantine failed: %v", err)
+	}
+
+	tests := []struct {
+		pkg, name string
+		rule      int // index of the matching rule, -1 if none
+	}{
+		{"package/name", "TestOne", -1},
+		{"package/name", "TestOne/sub", 0},
+		{"package/name", "TestOne/sub/nested", 0},
+		{"package/name", "TestOne/subtest", -1},
+		{"package/other", "TestOne/sub", -1},
+		{"package/other", "TestFlakyThing", 1},
+		{"package/other", "TestStable", -1},
+	}
+
+	for _, test := range tests {
+		for i, rule := range q {
+			if got, want := rule.Match(test.pkg, test.name), i == test.rule; got != want {
+				t.Errorf("rule %d Match(%q, %q) = %v, want %v", i, test.pkg, test.name, got, want)
+			}
+		}
+	}
+}
+
+func TestReadQuarantineInvalid(t *testing.T) {
+	for _, input := range []string{`{}`, `[{"test": ""}]`, `[{"test": "Test("}]`} {
+		if _, err := ReadQuarantine(strings.NewReader(input)); err == nil {
+			t.Errorf("ReadQuarantine(%q) did not return an error", input)
+		}
+	}
+}
+
+func TestQuarantineApply(t *testing.T) {
+	q, err := ReadQuarantine(strings.NewReader(`[{"test": "TestParent/flaky", "owner": "team", "ticket": "BUG-1"}]`))
+	if err != nil {
+		t.Fatalf("ReadQuarantine failed: %v", err)
+	}
+
+	report := gtr.Report{
+		Packages: []gtr.Package{
+			{
+				Name: "package/name",
+				Tests: []gtr.Test{
+					{ID: 1, Name: "TestParent", Result: gtr.Fail},
+					{ID: 2, Name: "TestParent/flaky", Result: gtr.Fail, Output: []string{"oops"}},
+					{ID: 3, Name: "TestParent/stable", Result: gtr.Pass},
+					{ID: 4, Name: "TestOther", Result: gtr.Fail},
+				},
+			},
+		},
+	}
+	props := []gtr.Property{{Name: "quarantine.ticket", Value: "BUG-1"}, {Name: "quarantine.owner", Value: "team"}}
+	want := []gtr.Test{
+		{ID: 1, Name: "TestParent", Result: gtr.Skip, Properties: props},
+		{ID: 2, Name: "TestParent/flaky", Result: gtr.Skip, Output: []string{"oops"}, Properties: props},
+		{ID: 3, Name: "TestParent/stable", Result: gtr.Pass},
+		{ID: 4, Name: "TestOther", Result: gtr.Fail},
+	}
+
+	if HasQuarantinedFailures(report) {
+		t.Errorf("HasQuarantinedFailures returned true before Apply")
+	}
+	q.Apply(&report)
+	if diff := cmp.Diff(want, report.Packages[0].Tests); diff != "" {
+		t.Errorf("Apply incorrect, diff (-want, +got):\n%s\n", diff)
+	}
+	if !HasQuarantinedFailures(report) {
+		t.Errorf("HasQuarantinedFailures returned false after Apply")
+	}
+}
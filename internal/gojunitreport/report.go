@@ -0,0 +1,56 @@
+package gojunitreport
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+
+	"github.com/jstemmer/go-junit-report/v2/gtr"
+	"github.com/jstemmer/go-junit-report/v2/junit"
+	"github.com/jstemmer/go-junit-report/v2/parser/gotest"
+)
+
+// ReadReport reads a previously created report from r. The format of the
+// report is detected automatically, it can be a JUnit XML report, a gtr JSON
+// report, `go test -json` output or regular `go test` output.
+func ReadReport(r io.Reader) (gtr.Report, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return gtr.Report{}, err
+	}
+
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return gtr.Report{}, nil
+	}
+
+	switch trimmed[0] {
+	case '<':
+		suites, err := junit.ReadXML(bytes.NewReader(data))
+		if err != nil {
+			return gtr.Report{}, err
+		}
+		return suites.ToReport(), nil
+	case '{':
+		if isGtrJSON(trimmed) {
+			return gtr.ReadJSON(bytes.NewReader(data))
+		}
+		return gotest.NewJSONParser().Parse(bytes.NewReader(data))
+	default:
+		return gotest.NewParser().Parse(bytes.NewReader(data))
+	}
+}
+
+// isGtrJSON returns true if data starts with a JSON object containing a
+// report written by gtr.Report.WriteJSON, rather than a `go test -json`
+// event.
+func isGtrJSON(data []byte) bool {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	var obj map[string]json.RawMessage
+	if err := dec.Decode(&obj); err != nil {
+		return false
+	}
+	_, ok := obj["Packages"]
+	return ok
+}
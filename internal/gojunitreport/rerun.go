@@ -0,0 +1,164 @@
+package gojunitreport
+
+import (
+	"encoding/json"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jstemmer/go-junit-report/v2/gtr"
+)
+
+// RerunCommand is a `go test` invocation that reruns the failed tests of a
+// single package.
+type RerunCommand struct {
+	Package string   `json:"package"`
+	Run     string   `json:"run,omitempty"` // -run pattern, empty to run all tests
+	Tests   []string `json:"tests,omitempty"`
+}
+
+// Args returns the arguments of the `go test` command.
+func (c RerunCommand) Args() []string {
+	args := []string{"go", "test"}
+	if c.Run != "" {
+		args = append(args, "-run", c.Run)
+	}
+	return append(args, c.Package)
+}
+
+// String returns the `go test` command, quoted for use in a POSIX shell.
+func (c RerunCommand) String() string {
+	args := c.Args()
+	for i, arg := range args {
+		args[i] = shellQuote(arg)
+	}
+	return strings.Join(args, " ")
+}
+
+// RerunCommands returns the `go test` commands that rerun only the tests that
+// failed in report. If a test failed because some of its subtests failed, only
+// those subtests are rerun. Failed subtests that share the same parent test are
+// combined into a single command, as are failed top-level tests in the same
+// package. Packages that failed without any failing tests, for example due to
+// a build error, are rerun entirely. Benchmarks and the testcases added for
+// packages below their minimum coverage are not rerun.
+func RerunCommands(report gtr.Report) []RerunCommand {
+	var cmds []RerunCommand
+	for _, pkg := range report.Packages {
+		var failed []string
+		for _, test := range pkg.Tests {
+			if isFailure(test.Result) && test.Kind != gtr.KindBenchmark && !isCoverageTest(test) {
+				failed = append(failed, test.Name)
+			}
+		}
+		sort.Strings(failed)
+
+		if len(failed) == 0 {
+			if pkg.BuildError.Name != "" || pkg.RunError.Name != "" {
+				cmds = append(cmds, RerunCommand{Package: pkg.Name})
+			}
+			continue
+		}
+
+		// Group the failed tests that have no failed subtests by their parent.
+		var parents []string
+		groups := make(map[string][]string)
+		for _, name := range failed {
+			if hasFailedSubtest(name, failed) {
+				continue
+			}
+			parent := ""
+			if idx := strings.LastIndex(name, "/"); idx >= 0 {
+				parent = name[:idx]
+			}
+			if _, ok := groups[parent]; !ok {
+				parents = append(parents, parent)
+			}
+			groups[parent] = append(groups[parent], name)
+		}
+
+		for _, parent := range parents {
+			tests := groups[parent]
+			cmds = append(cmds, RerunCommand{
+				Package: pkg.Name,
+				Run:     rerunPattern(parent, tests),
+				Tests:   tests,
+			})
+		}
+	}
+	return cmds
+}
+
+// hasFailedSubtest returns true if any of the failed tests is a subtest of
+// the test with the given name.
+func hasFailedSubtest(name string, failed []string) bool {
+	for _, f := range failed {
+		if strings.HasPrefix(f, name+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// rerunPattern returns a -run pattern that matches exactly the given tests,
+// which must all have the given parent. Go splits the pattern on slashes and
+// matches each element against the corresponding level of the test name, so
+// each element is anchored and escaped separately.
+func rerunPattern(parent string, tests []string) string {
+	var elems []string
+	if parent != "" {
+		for _, elem := range strings.Split(parent, "/") {
+			elems = append(elems, "^"+regexp.QuoteMeta(elem)+"$")
+		}
+	}
+
+	names := make([]string, len(tests))
+	for i, test := range tests {
+		names[i] = regexp.QuoteMeta(strings.TrimPrefix(test, parent+"/"))
+	}
+	if len(names) == 1 {
+		elems = append(elems, "^"+names[0]+"$")
+	} else {
+		elems = append(elems, "^("+strings.Join(names, "|")+")$")
+	}
+	return strings.Join(elems, "/")
+}
+
+// shellQuote quotes s for use in a POSIX shell, if necessary.
+func shellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:=@+,", r))
+	}) < 0 {
+		return s
+	}
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// WriteRerunCommands writes the commands to w, one per line, so that the
+// output can be run as a shell script.
+func WriteRerunCommands(w io.Writer, cmds []RerunCommand) error {
+	var b strings.Builder
+	for _, cmd := range cmds {
+		b.WriteString(cmd.String())
+		b.WriteString("\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteRerunJSON writes the commands to w as a JSON array. Each command also
+// contains the arguments of the `go test` invocation.
+func WriteRerunJSON(w io.Writer, cmds []RerunCommand) error {
+	type jsonCommand struct {
+		RerunCommand
+		Command []string `json:"command"`
+	}
+	out := []jsonCommand{}
+	for _, cmd := range cmds {
+		out = append(out, jsonCommand{cmd, cmd.Args()})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(out)
+}
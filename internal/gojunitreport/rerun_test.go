@@ -0,0 +1,75 @@
+package gojunitreport
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/jstemmer/go-junit-report/v2/gtr"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRerunCommands(t *testing.T) {
+	newTest := func(name string, result gtr.Result) gtr.Test {
+		test := gtr.NewTest(0, name)
+		test.Result = result
+		return test
+	}
+
+	report := gtr.Report{
+		Packages: []gtr.Package{
+			{
+				Name: "package/name",
+				Tests: []gtr.Test{
+					newTest("TestPass", gtr.Pass),
+					newTest("TestFail", gtr.Fail),
+					newTest("TestPanic", gtr.Unknown),
+					newTest("TestParent", gtr.Fail),
+					newTest("TestParent/sub_test", gtr.Fail),
+					newTest("TestParent/ok", gtr.Pass),
+					newTest("TestParent/a+b", gtr.Fail),
+					newTest("TestParent/nested", gtr.Fail),
+					newTest("TestParent/nested/deep(1)", gtr.Fail),
+					newTest("BenchmarkFail", gtr.Fail),
+				},
+			},
+			{
+				Name:       "package/broken",
+				BuildError: gtr.Error{Name: "package/broken"},
+			},
+			{
+				Name:  "package/ok",
+				Tests: []gtr.Test{newTest("TestPass", gtr.Pass)},
+			},
+		},
+	}
+	AddCoverageFailures(&report, []CoverageShortfall{
+		{Package: "package/ok", Coverage: 10, Threshold: 50},
+		{Coverage: 10, Threshold: 50},
+	})
+
+	want := []RerunCommand{
+		{Package: "package/name", Run: `^(TestFail|TestPanic)$`, Tests: []string{"TestFail", "TestPanic"}},
+		{Package: "package/name", Run: `^TestParent$/^(a\+b|sub_test)$`, Tests: []string{"TestParent/a+b", "TestParent/sub_test"}},
+		{Package: "package/name", Run: `^TestParent$/^nested$/^deep\(1\)$`, Tests: []string{"TestParent/nested/deep(1)"}},
+		{Package: "package/broken"},
+	}
+
+	got := RerunCommands(report)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("RerunCommands incorrect, diff (-want, +got):\n%s\n", diff)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteRerunCommands(&buf, got); err != nil {
+		t.Fatalf("WriteRerunCommands failed: %v", err)
+	}
+	wantCommands := `go test -run '^(TestFail|TestPanic)$' package/name
+go test -run '^TestParent$/^(a\+b|sub_test)$' package/name
+go test -run '^TestParent$/^nested$/^deep\(1\)$' package/name
+go test package/broken
+`
+	if diff := cmp.Diff(wantCommands, buf.String()); diff != "" {
+		t.Errorf("WriteRerunCommands incorrect, diff (-want, +got):\n%s\n", diff)
+	}
+}
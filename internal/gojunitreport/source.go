@@ -0,0 +1,195 @@
+package gojunitreport
+
+import (
+	"bufio"
+	"fmt"
+	"go/ast"
+	goparser "go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/jstemmer/go-junit-report/v2/gtr"
+)
+
+var regexCPUSuffix = regexp.MustCompile(`-\d+$`)
+
+// sourceLocation is the location of a test function declaration.
+type sourceLocation struct {
+	file string // slash separated path relative to the module root
+	line int
+}
+
+// sourceResolver finds the source files of tests in a Go module.
+type sourceResolver struct {
+	root       string
+	modulePath string
+
+	// packages contains the test function locations in each package,
+	// indexed by import path and function name.
+	packages map[string]map[string]sourceLocation
+}
+
+// newSourceResolver returns a sourceResolver for the Go module in the given
+// root directory.
+func newSourceResolver(root string) (*sourceResolver, error) {
+	modulePath, err := readModulePath(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return nil, err
+	}
+	return &sourceResolver{
+		root:       root,
+		modulePath: modulePath,
+		packages:   make(map[string]map[string]sourceLocation),
+	}, nil
+}
+
+// Resolve sets the file and line of all tests in the report whose package
+// belongs to this module. Subtests get the location of their top-level test.
+// Failure locations are made relative to the module root.
+func (r *sourceResolver) Resolve(report *gtr.Report) error {
+	for i := range report.Packages {
+		pkg := &report.Packages[i]
+		dir, ok := r.packageDir(pkg.Name)
+		if !ok {
+			continue
+		}
+		funcs, err := r.testFuncs(pkg.Name, dir)
+		if err != nil {
+			return err
+		}
+
+		for j := range pkg.Tests {
+			test := &pkg.Tests[j]
+			name := strings.SplitN(test.Name, "/", 2)[0]
+			loc, ok := funcs[name]
+			if !ok {
+				loc, ok = funcs[regexCPUSuffix.ReplaceAllString(name, "")]
+			}
+			if ok {
+				test.File, test.Line = loc.file, loc.line
+			}
+
+			if f := test.Failure.File; f != "" && !strings.Contains(f, "/") {
+				if _, err := os.Stat(filepath.Join(dir, f)); err == nil {
+					test.Failure.File = r.relPath(filepath.Join(dir, f))
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// packageDir returns the directory of the package with the given import path,
+// if it belongs to this module.
+func (r *sourceResolver) packageDir(importPath string) (string, bool) {
+	if importPath == r.modulePath {
+		return r.root, true
+	}
+	if strings.HasPrefix(importPath, r.modulePath+"/") {
+		rel := strings.TrimPrefix(importPath, r.modulePath+"/")
+		return filepath.Join(r.root, filepath.FromSlash(rel)), true
+	}
+	return "", false
+}
+
+// testFuncs returns the locations of the Test, Benchmark, Example and Fuzz
+// functions declared in the _test.go files in dir. Files that cannot be
+// parsed, e.g. because they contain syntax errors, are skipped.
+func (r *sourceResolver) testFuncs(importPath, dir string) (map[string]sourceLocation, error) {
+	if funcs, ok := r.packages[importPath]; ok {
+		return funcs, nil
+	}
+
+	funcs := make(map[string]sourceLocation)
+	r.packages[importPath] = funcs
+
+	entries, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return funcs, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), "_test.go") {
+			continue
+		}
+		filename := filepath.Join(dir, entry.Name())
+		file, err := goparser.ParseFile(fset, filename, nil, 0)
+		if err != nil {
+			continue
+		}
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv != nil || !isTestFunc(fn.Name.Name) {
+				continue
+			}
+			funcs[fn.Name.Name] = sourceLocation{
+				file: r.relPath(filename),
+				line: fset.Position(fn.Pos()).Line,
+			}
+		}
+	}
+	return funcs, nil
+}
+
+// relPath returns filename relative to the module root, using forward
+// slashes.
+func (r *sourceResolver) relPath(filename string) string {
+	rel, err := filepath.Rel(r.root, filename)
+	if err != nil {
+		return filepath.ToSlash(filename)
+	}
+	return path.Clean(filepath.ToSlash(rel))
+}
+
+// isTestFunc returns true if name looks like the name of a function that is
+// run by `go test`.
+func isTestFunc(name string) bool {
+	for _, prefix := range []string{"Test", "Benchmark", "Example", "Fuzz"} {
+		if !strings.HasPrefix(name, prefix) || name == "TestMain" {
+			continue
+		}
+		rest := name[len(prefix):]
+		if rest == "" {
+			return true
+		}
+		r, _ := utf8.DecodeRuneInString(rest)
+		return !unicode.IsLower(r)
+	}
+	return false
+}
+
+// readModulePath returns the module path declared in the given go.mod file.
+func readModulePath(gomod string) (string, error) {
+	f, err := os.Open(gomod)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) < 2 || fields[0] != "module" {
+			continue
+		}
+		if unquoted, err := strconv.Unquote(fields[1]); err == nil {
+			return unquoted, nil
+		}
+		return fields[1], nil
+	}
+	if err := s.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("no module path found in %s", gomod)
+}
@@ -0,0 +1,130 @@
+package gojunitreport
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jstemmer/go-junit-report/v2/gtr"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSourceResolver(t *testing.T) {
+	report := gtr.Report{
+		Packages: []gtr.Package{
+			{
+				Name: "package/subtests",
+				Tests: []gtr.Test{
+					{Name: "TestSubtests"},
+					{Name: "TestSubtests/Subtest#01", Result: gtr.Fail, Failure: gtr.Failure{Message: "error message", File: "subtests_test.go", Line: 10}},
+					{Name: "TestUnknown"},
+				},
+			},
+			{
+				Name:  "package/bench",
+				Tests: []gtr.Test{{Name: "BenchmarkOne-8"}},
+			},
+			{
+				Name:  "other/package",
+				Tests: []gtr.Test{{Name: "TestSubtests"}},
+			},
+		},
+	}
+
+	want := gtr.Report{
+		Packages: []gtr.Package{
+			{
+				Name: "package/subtests",
+				Tests: []gtr.Test{
+					{Name: "TestSubtests", File: "subtests/subtests_test.go", Line: 5},
+					{
+						Name:    "TestSubtests/Subtest#01",
+						Result:  gtr.Fail,
+						File:    "subtests/subtests_test.go",
+						Line:    5,
+						Failure: gtr.Failure{Message: "error message", File: "subtests/subtests_test.go", Line: 10},
+					},
+					{Name: "TestUnknown"},
+				},
+			},
+			{
+				Name:  "package/bench",
+				Tests: []gtr.Test{{Name: "BenchmarkOne-8", File: "bench/bench_test.go", Line: 12}},
+			},
+			{
+				Name:  "other/package",
+				Tests: []gtr.Test{{Name: "TestSubtests"}},
+			},
+		},
+	}
+
+	resolver, err := newSourceResolver(testDataDir + "src")
+	if err != nil {
+		t.Fatalf("newSourceResolver failed: %v", err)
+	}
+	if err := resolver.Resolve(&report); err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if diff := cmp.Diff(want, report); diff != "" {
+		t.Errorf("Resolve incorrect, diff (-want, +got):\n%s\n", diff)
+	}
+}
+
+func TestSourceResolverParseError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-junit-report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"go.mod":         "module example.com/mod\n",
+		"a_test.go":      "package mod\n\nfunc TestA(t *testing.T) {}\n",
+		"broken_test.go": "package mod\n\nfunc TestBroken(t *testing.T) {\n",
+	}
+	for name, data := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report := gtr.Report{
+		Packages: []gtr.Package{
+			{Name: "example.com/mod", Tests: []gtr.Test{{Name: "TestA"}, {Name: "TestBroken"}}},
+		},
+	}
+	resolver, err := newSourceResolver(dir)
+	if err != nil {
+		t.Fatalf("newSourceResolver failed: %v", err)
+	}
+	if err := resolver.Resolve(&report); err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+
+	want := []gtr.Test{{Name: "TestA", File: "a_test.go", Line: 3}, {Name: "TestBroken"}}
+	if diff := cmp.Diff(want, report.Packages[0].Tests); diff != "" {
+		t.Errorf("Resolve incorrect, diff (-want, +got):\n%s\n", diff)
+	}
+}
+
+func TestIsTestFunc(t *testing.T) {
+	tests := map[string]bool{
+		"Test":         true,
+		"TestOne":      true,
+		"Test_one":     true,
+		"Testing":      false,
+		"TestMain":     false,
+		"BenchmarkOne": true,
+		"Example":      true,
+		"ExampleFoo":   true,
+		"FuzzReverse":  true,
+		"helper":       false,
+	}
+	for name, want := range tests {
+		if got := isTestFunc(name); got != want {
+			t.Errorf("isTestFunc(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,331 @@
+package gojunitreport
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jstemmer/go-junit-report/v2/gtr"
+)
+
+// TruncatePolicy determines which lines are kept when output is truncated.
+type TruncatePolicy string
+
+const (
+	// TruncateHeadTail keeps the first and the last lines of the output and
+	// replaces the lines in between with a marker. This is the default.
+	TruncateHeadTail TruncatePolicy = "head-tail"
+
+	// TruncateHead keeps the first lines of the output.
+	TruncateHead TruncatePolicy = "head"
+
+	// TruncateTail keeps the last lines of the output.
+	TruncateTail TruncatePolicy = "tail"
+)
+
+// ParseTruncatePolicy returns a TruncatePolicy for the given string.
+func ParseTruncatePolicy(in string) (TruncatePolicy, error) {
+	switch in {
+	case string(TruncateHeadTail):
+		return TruncateHeadTail, nil
+	case string(TruncateHead):
+		return TruncateHead, nil
+	case string(TruncateTail):
+		return TruncateTail, nil
+	default:
+		return "", fmt.Errorf("unknown truncate policy: %v", in)
+	}
+}
+
+// OutputLimits contains the maximum size in bytes of the output in a report,
+// including a newline for each line. Limits that are 0 are not enforced. When
+// a package or the report exceeds its limit, the largest outputs in it are
+// truncated first, so that small outputs are kept intact.
+type OutputLimits struct {
+	Test    int // limit for the output of a single test
+	Package int // limit for the output of a package and all of its tests
+	Total   int // limit for all output in the report
+
+	Policy TruncatePolicy
+}
+
+// enabled returns true if any of the limits are set.
+func (l OutputLimits) enabled() bool {
+	return l.Test > 0 || l.Package > 0 || l.Total > 0
+}
+
+// outputRef refers to the lines of output of a test or package, and where to
+// record that it was truncated.
+type outputRef struct {
+	lines  *[]string
+	prefix string // property name prefix, i.e. "output" or "stderr"
+	props  *[]gtr.Property
+	size   int
+	limit  int
+}
+
+// Apply truncates the output of tests and packages in report that exceed the
+// limits. The number of omitted lines and bytes of each truncated output are
+// added to its test or package as properties, e.g. "output.omitted.lines".
+// The output of build and run errors counts towards the package limit, and is
+// recorded as e.g. "build_error.output.omitted.lines". The output of earlier
+// attempts of a test counts towards the test limit of each attempt, and is
+// recorded on the test as e.g. "attempt.1.output.omitted.lines". Output that
+// was already truncated, e.g. by ApplyPackage, is truncated again if needed,
+// and its properties then count the lines omitted by both truncations.
+func (l OutputLimits) Apply(report *gtr.Report) {
+	if !l.enabled() {
+		return
+	}
+
+	var all []*outputRef
+	for i := range report.Packages {
+		all = append(all, l.packageRefs(&report.Packages[i])...)
+	}
+	if l.Total > 0 {
+		applyLimit(all, l.Total)
+	}
+	l.truncate(all)
+}
+
+// ApplyPackage truncates the output of pkg and its tests that exceed the Test
+// and Package limits, like Apply. The Total limit is not applied. This can be
+// used to truncate each package as soon as it has been parsed, so that only
+// the truncated output of a large input is kept in memory.
+func (l OutputLimits) ApplyPackage(pkg *gtr.Package) {
+	if !l.enabled() {
+		return
+	}
+	l.truncate(l.packageRefs(pkg))
+}
+
+// packageRefs returns references to all output in pkg, whose limits have been
+// lowered to the Test and Package limits.
+func (l OutputLimits) packageRefs(pkg *gtr.Package) []*outputRef {
+	refs := newOutputRefs(&pkg.Output, &pkg.Stderr, "", &pkg.Properties, 0)
+	refs = append(refs,
+		newOutputRef(&pkg.BuildError.Output, "build_error.output", &pkg.Properties, 0),
+		newOutputRef(&pkg.RunError.Output, "run_error.output", &pkg.Properties, 0))
+	for j := range pkg.Tests {
+		test := &pkg.Tests[j]
+		refs = append(refs, newOutputRefs(&test.Output, &test.Stderr, "", &test.Properties, l.Test)...)
+		for k := range test.Attempts {
+			a := &test.Attempts[k]
+			prefix := fmt.Sprintf("attempt.%d.", k+1)
+			moveOmittedProperties(&a.Properties, &test.Properties, prefix)
+			refs = append(refs, newOutputRefs(&a.Output, &a.Stderr, prefix, &test.Properties, l.Test)...)
+		}
+	}
+	if l.Package > 0 {
+		applyLimit(refs, l.Package)
+	}
+	return refs
+}
+
+// truncate truncates the output of refs that exceed their limit, and records
+// the number of omitted lines and bytes as properties.
+func (l OutputLimits) truncate(refs []*outputRef) {
+	for _, ref := range refs {
+		if ref.limit >= ref.size {
+			continue
+		}
+
+		// If the output was truncated before, the old marker is replaced and
+		// the lines it stood for are included in the new counts.
+		lines := *ref.lines
+		prevLines := intProperty(*ref.props, ref.prefix+".omitted.lines")
+		prevBytes := intProperty(*ref.props, ref.prefix+".omitted.bytes")
+		marker := -1
+		if prevLines > 0 {
+			marker = indexOf(lines, omittedMarker(prevLines))
+		}
+
+		kept, omitted := truncateLines(lines, ref.limit, l.Policy, marker, prevLines)
+		omittedLines, omittedBytes := len(omitted)+prevLines, outputSize(omitted)+prevBytes
+		if marker >= 0 {
+			omittedLines--
+			omittedBytes -= len(lines[marker]) + 1
+		}
+		setProperty(ref.props, ref.prefix+".omitted.lines", strconv.Itoa(omittedLines))
+		setProperty(ref.props, ref.prefix+".omitted.bytes", strconv.Itoa(omittedBytes))
+		*ref.lines = kept
+	}
+}
+
+// newOutputRefs returns references to the given output and stderr lines,
+// whose property names start with prefix. If limit is larger than 0, it is
+// used as their initial limit.
+func newOutputRefs(output, stderr *[]string, prefix string, props *[]gtr.Property, limit int) []*outputRef {
+	return []*outputRef{
+		newOutputRef(output, prefix+"output", props, limit),
+		newOutputRef(stderr, prefix+"stderr", props, limit),
+	}
+}
+
+// newOutputRef returns a reference to the given lines. If limit is larger
+// than 0, it is used as its initial limit.
+func newOutputRef(lines *[]string, prefix string, props *[]gtr.Property, limit int) *outputRef {
+	ref := &outputRef{lines: lines, prefix: prefix, props: props}
+	ref.size = outputSize(*lines)
+	ref.limit = ref.size
+	if limit > 0 && ref.limit > limit {
+		ref.limit = limit
+	}
+	return ref
+}
+
+// moveOmittedProperties moves the properties recording truncated output from
+// an attempt to its test, prepending prefix to their names. An attempt has
+// these properties if it was truncated before the attempts of the test were
+// grouped.
+func moveOmittedProperties(from, to *[]gtr.Property, prefix string) {
+	var kept []gtr.Property
+	for _, p := range *from {
+		if strings.HasPrefix(p.Name, "output.omitted.") || strings.HasPrefix(p.Name, "stderr.omitted.") {
+			setProperty(to, prefix+p.Name, p.Value)
+		} else {
+			kept = append(kept, p)
+		}
+	}
+	*from = kept
+}
+
+// intProperty returns the integer value of the property with the given name,
+// or 0 if there is none.
+func intProperty(props []gtr.Property, name string) int {
+	for _, p := range props {
+		if p.Name == name {
+			n, _ := strconv.Atoi(p.Value)
+			return n
+		}
+	}
+	return 0
+}
+
+// setProperty sets the value of the property with the given name, or appends
+// a new property if there is none.
+func setProperty(props *[]gtr.Property, name, value string) {
+	for i := range *props {
+		if (*props)[i].Name == name {
+			(*props)[i].Value = value
+			return
+		}
+	}
+	*props = append(*props, gtr.Property{Name: name, Value: value})
+}
+
+// indexOf returns the index of the first line equal to s, or -1.
+func indexOf(lines []string, s string) int {
+	for i, line := range lines {
+		if line == s {
+			return i
+		}
+	}
+	return -1
+}
+
+// applyLimit lowers the limits of refs so that their sum does not exceed
+// limit. The largest limits are lowered first, so that all limits that are
+// lowered end up equal.
+func applyLimit(refs []*outputRef, limit int) {
+	sizes := make([]int, len(refs))
+	for i, ref := range refs {
+		sizes[i] = ref.limit
+	}
+	sort.Ints(sizes)
+
+	remaining := limit
+	for i, size := range sizes {
+		n := len(sizes) - i
+		if size*n <= remaining {
+			remaining -= size
+			continue
+		}
+		share := remaining / n
+		for _, ref := range refs {
+			if ref.limit > share {
+				ref.limit = share
+			}
+		}
+		return
+	}
+}
+
+// outputSize returns the size in bytes of lines, including newlines.
+func outputSize(lines []string) int {
+	size := 0
+	for _, line := range lines {
+		size += len(line) + 1
+	}
+	return size
+}
+
+// truncateLines returns the lines that fit within limit bytes according to
+// policy, and the lines that were omitted. A marker line is added in place of
+// the omitted lines, unless the marker itself does not fit within limit. If
+// lines were truncated before, marker is the index of the old marker line,
+// which is always omitted, and prev is the number of lines it stood for. The
+// new marker then includes those lines. Otherwise marker is -1 and prev is 0.
+func truncateLines(lines []string, limit int, policy TruncatePolicy, marker, prev int) (kept, omitted []string) {
+	// Reserve room for the marker, assuming every line is omitted.
+	markerSize := len(omittedMarker(len(lines)+prev)) + 1
+	addMarker := limit >= markerSize
+	if addMarker {
+		limit -= markerSize
+	}
+
+	var head, tail int // number of lines to keep at the start and end
+	switch policy {
+	case TruncateHead:
+		head = fitLines(lines, limit, false)
+	case TruncateTail:
+		tail = fitLines(lines, limit, true)
+	default:
+		head = fitLines(lines, limit/2, false)
+		tail = fitLines(lines[head:], limit-outputSize(lines[:head]), true)
+	}
+	if marker >= 0 {
+		if head > marker {
+			head = marker
+		}
+		if tail > len(lines)-marker-1 {
+			tail = len(lines) - marker - 1
+		}
+	}
+
+	omitted = lines[head : len(lines)-tail]
+	n := len(omitted) + prev
+	if marker >= 0 {
+		n--
+	}
+	kept = make([]string, 0, head+tail+1)
+	kept = append(kept, lines[:head]...)
+	if addMarker {
+		kept = append(kept, omittedMarker(n))
+	}
+	kept = append(kept, lines[len(lines)-tail:]...)
+	return kept, omitted
+}
+
+// omittedMarker returns the line that replaces n omitted lines.
+func omittedMarker(n int) string {
+	return fmt.Sprintf("... %d lines omitted ...", n)
+}
+
+// fitLines returns the number of lines from the start of lines, or from the
+// end if fromEnd is true, that fit within limit bytes.
+func fitLines(lines []string, limit int, fromEnd bool) int {
+	size := 0
+	for n := 0; n < len(lines); n++ {
+		line := lines[n]
+		if fromEnd {
+			line = lines[len(lines)-1-n]
+		}
+		size += len(line) + 1
+		if size > limit {
+			return n
+		}
+	}
+	return len(lines)
+}
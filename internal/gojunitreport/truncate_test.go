@@ -0,0 +1,182 @@
+package gojunitreport
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jstemmer/go-junit-report/v2/gtr"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// numberedLines returns n lines of 4 bytes each, including the newline.
+func numberedLines(n int) []string {
+	var lines []string
+	for i := 1; i <= n; i++ {
+		lines = append(lines, fmt.Sprintf("%03d", i))
+	}
+	return lines
+}
+
+func TestTruncateLines(t *testing.T) {
+	tests := []struct {
+		policy TruncatePolicy
+		want   []string
+	}{
+		{TruncateHead, []string{"001", "002", "003", "... 7 lines omitted ..."}},
+		{TruncateTail, []string{"... 7 lines omitted ...", "008", "009", "010"}},
+		{TruncateHeadTail, []string{"001", "... 7 lines omitted ...", "009", "010"}},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.policy), func(t *testing.T) {
+			got, omitted := truncateLines(numberedLines(10), 37, test.policy, -1, 0)
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("truncateLines incorrect, diff (-want, +got):\n%s", diff)
+			}
+			if len(omitted) != 7 {
+				t.Errorf("truncateLines omitted %d lines, want 7", len(omitted))
+			}
+			if size := outputSize(got); size > 37 {
+				t.Errorf("truncateLines returned %d bytes, want at most 37", size)
+			}
+		})
+	}
+
+	got, _ := truncateLines(numberedLines(10), 12, TruncateHead, -1, 0)
+	if diff := cmp.Diff([]string{"001", "002", "003"}, got); diff != "" {
+		t.Errorf("truncateLines without room for marker incorrect, diff (-want, +got):\n%s", diff)
+	}
+}
+
+func TestOutputLimits(t *testing.T) {
+	newReport := func() gtr.Report {
+		return gtr.Report{
+			Packages: []gtr.Package{
+				{
+					Name:   "package/name",
+					Output: numberedLines(2),
+					Tests: []gtr.Test{
+						{Name: "TestSmall", Output: numberedLines(2)},
+						{Name: "TestLarge", Output: numberedLines(20), Stderr: numberedLines(3)},
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		limits OutputLimits
+		want   []int // number of output lines: package output, small test, large test, large test stderr
+	}{
+		{"none", OutputLimits{}, []int{2, 2, 20, 3}},
+		{"test", OutputLimits{Test: 40}, []int{2, 2, 4, 3}},
+		{"package", OutputLimits{Package: 60, Policy: TruncateHead}, []int{2, 2, 2, 3}},
+		{"total", OutputLimits{Total: 40, Policy: TruncateTail}, []int{2, 2, 3, 3}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			report := newReport()
+			test.limits.Apply(&report)
+
+			pkg := report.Packages[0]
+			got := []int{len(pkg.Output), len(pkg.Tests[0].Output), len(pkg.Tests[1].Output), len(pkg.Tests[1].Stderr)}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Apply incorrect number of lines, diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+
+	report := newReport()
+	OutputLimits{Test: 40}.Apply(&report)
+	wantProps := []gtr.Property{
+		{Name: "output.omitted.lines", Value: "17"},
+		{Name: "output.omitted.bytes", Value: "68"},
+	}
+	if diff := cmp.Diff(wantProps, report.Packages[0].Tests[1].Properties); diff != "" {
+		t.Errorf("Apply incorrect properties, diff (-want, +got):\n%s", diff)
+	}
+	if props := report.Packages[0].Tests[0].Properties; len(props) > 0 {
+		t.Errorf("Apply added properties to test that was not truncated: %v", props)
+	}
+	if size := outputSize(report.Packages[0].Tests[1].Output); size > 40 {
+		t.Errorf("Apply left %d bytes of test output, want at most 40", size)
+	}
+}
+
+func TestOutputLimitsErrorsAndAttempts(t *testing.T) {
+	report := gtr.Report{
+		Packages: []gtr.Package{
+			{
+				Name:     "package/name",
+				RunError: gtr.Error{Name: "package/name", Output: numberedLines(20)},
+				Tests: []gtr.Test{
+					{
+						Name:   "TestRetried",
+						Output: numberedLines(2),
+						Attempts: []gtr.Test{
+							{Name: "TestRetried", Output: numberedLines(20)},
+							{Name: "TestRetried", Output: numberedLines(2)},
+						},
+					},
+				},
+			},
+		},
+	}
+	OutputLimits{Test: 40, Package: 80}.Apply(&report)
+
+	pkg := report.Packages[0]
+	if size := outputSize(pkg.RunError.Output); size > 40 {
+		t.Errorf("Apply left %d bytes of run error output, want at most 40", size)
+	}
+	if size := outputSize(pkg.Tests[0].Attempts[0].Output); size > 40 {
+		t.Errorf("Apply left %d bytes of attempt output, want at most 40", size)
+	}
+
+	wantPkgProps := []gtr.Property{
+		{Name: "run_error.output.omitted.lines", Value: "19"},
+		{Name: "run_error.output.omitted.bytes", Value: "76"},
+	}
+	if diff := cmp.Diff(wantPkgProps, pkg.Properties); diff != "" {
+		t.Errorf("Apply incorrect package properties, diff (-want, +got):\n%s", diff)
+	}
+	wantTestProps := []gtr.Property{
+		{Name: "attempt.1.output.omitted.lines", Value: "19"},
+		{Name: "attempt.1.output.omitted.bytes", Value: "76"},
+	}
+	if diff := cmp.Diff(wantTestProps, pkg.Tests[0].Properties); diff != "" {
+		t.Errorf("Apply incorrect test properties, diff (-want, +got):\n%s", diff)
+	}
+}
+
+func TestOutputLimitsApplyPackage(t *testing.T) {
+	newReport := func() gtr.Report {
+		return gtr.Report{
+			Packages: []gtr.Package{
+				{
+					Name: "package/name",
+					Tests: []gtr.Test{
+						{Name: "TestA", Output: numberedLines(30)},
+						{Name: "TestA", Output: numberedLines(30)},
+					},
+				},
+			},
+		}
+	}
+	limits := OutputLimits{Test: 80, Total: 120}
+
+	want := newReport()
+	want.Packages[0].GroupAttempts()
+	limits.Apply(&want)
+
+	got := newReport()
+	limits.ApplyPackage(&got.Packages[0])
+	got.Packages[0].GroupAttempts()
+	limits.Apply(&got)
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Apply after ApplyPackage incorrect, diff (-want, +got):\n%s", diff)
+	}
+}
@@ -6,7 +6,11 @@ import (
 	"encoding/xml"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"path"
+	"strconv"
 	"strings"
+	"text/template"
 	"time"
 
 	"github.com/jstemmer/go-junit-report/v2/gtr"
@@ -51,6 +55,218 @@ func (t *Testsuites) WriteXML(w io.Writer) error {
 	return err
 }
 
+// ReadXML reads a JUnit XML report from reader r. Both reports with a
+// <testsuites> root element and reports containing a single <testsuite> are
+// supported.
+func ReadXML(r io.Reader) (Testsuites, error) {
+	var suites Testsuites
+	dec := xml.NewDecoder(r)
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return suites, err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "testsuites":
+			err = dec.DecodeElement(&suites, &start)
+		case "testsuite":
+			var suite Testsuite
+			if err = dec.DecodeElement(&suite, &start); err == nil {
+				suites.AddSuite(suite)
+			}
+		default:
+			err = fmt.Errorf("unexpected root element <%s>", start.Name.Local)
+		}
+		return suites, err
+	}
+}
+
+// ToReport converts the testsuites t into a gtr.Report. This is the inverse
+// of CreateFromReport, but since not all information is preserved in a JUnit
+// report the resulting gtr.Report may not be identical to the original.
+func (t Testsuites) ToReport() gtr.Report {
+	var report gtr.Report
+	id := 1
+	for _, suite := range t.Suites {
+		pkg := gtr.Package{
+			Name:     suite.Name,
+			Duration: parseDuration(suite.Time),
+		}
+		if ts, err := time.Parse(time.RFC3339, suite.Timestamp); err == nil {
+			pkg.Timestamp = ts
+		}
+
+		if suite.Properties != nil {
+			for _, p := range *suite.Properties {
+				if p.Name == "coverage.statements.pct" {
+					pkg.Coverage, _ = strconv.ParseFloat(p.Value, 64)
+					pkg.HasCoverage = true
+					continue
+				}
+				pkg.AddProperty(p.Name, p.Value)
+			}
+		}
+
+		if suite.SystemOut != nil {
+			pkg.Output = splitOutput(suite.SystemOut.Data)
+		}
+		if suite.SystemErr != nil {
+			pkg.Stderr = splitOutput(suite.SystemErr.Data)
+		}
+
+		for _, tc := range flattenTestcases(suite.Name, suite) {
+			if tc.Error != nil && tc.Error.Message == "Build error" {
+				pkg.BuildError = gtr.Error{
+					ID:     id,
+					Name:   tc.Classname,
+					Cause:  tc.Name,
+					Output: splitOutput(tc.Error.Data),
+				}
+			} else if tc.Error != nil && tc.Error.Message == "Runtime error" {
+				pkg.RunError = gtr.Error{
+					ID:     id,
+					Name:   tc.Classname,
+					Output: splitOutput(tc.Error.Data),
+				}
+			} else {
+				pkg.Tests = append(pkg.Tests, createTestFromTestcase(id, tc))
+			}
+			id++
+		}
+		setParentIDs(pkg.Tests)
+		report.Packages = append(report.Packages, pkg)
+	}
+	return report
+}
+
+// flattenTestcases returns the testcases in suite and its nested testsuites,
+// see NestedSuites. Nested testsuites without a testcase for the test they
+// represent are converted into a testcase for that test. Testcases that use
+// the ParentClassname format get their full test name.
+func flattenTestcases(pkgName string, suite Testsuite) []Testcase {
+	var testcases []Testcase
+	for _, tc := range suite.Testcases {
+		if strings.HasPrefix(tc.Classname, pkgName+".") {
+			tc.Name = strings.TrimPrefix(tc.Classname, pkgName+".") + "/" + tc.Name
+			tc.Classname = pkgName
+		}
+		testcases = append(testcases, tc)
+	}
+
+	for _, nested := range suite.Suites {
+		children := flattenTestcases(pkgName, nested)
+		if len(children) == 0 || children[0].Name != nested.Name {
+			parent := Testcase{
+				Name:      nested.Name,
+				Classname: pkgName,
+				Time:      nested.Time,
+				SystemOut: nested.SystemOut,
+				SystemErr: nested.SystemErr,
+			}
+			if nested.Properties != nil {
+				parent.Properties = nested.Properties
+			}
+			if nested.Failures > 0 {
+				parent.Failure = &Result{Message: "Failed"}
+				if nested.SystemOut != nil {
+					parent.Failure.Data = nested.SystemOut.Data
+					parent.SystemOut = nil
+				}
+			}
+			children = append([]Testcase{parent}, children...)
+		}
+		testcases = append(testcases, children...)
+	}
+	return testcases
+}
+
+// setParentIDs sets the ParentID of each subtest in tests to the id of the
+// closest preceding test whose name is a prefix of the subtest name.
+func setParentIDs(tests []gtr.Test) {
+	for i := range tests {
+		name := tests[i].Name
+		for j := i - 1; j >= 0; j-- {
+			if strings.HasPrefix(name, tests[j].Name+"/") {
+				tests[i].ParentID = tests[j].ID
+				break
+			}
+		}
+	}
+}
+
+func createTestFromTestcase(id int, tc Testcase) gtr.Test {
+	test := gtr.NewTest(id, tc.Name)
+	test.Duration = parseDuration(tc.Time)
+	test.Level = strings.Count(tc.Name, "/")
+	test.File = tc.File
+	test.Line = tc.Line
+
+	if tc.Properties != nil {
+		for _, p := range *tc.Properties {
+			test.AddProperty(p.Name, p.Value)
+		}
+	}
+
+	if tc.Failure != nil {
+		test.Result = gtr.Fail
+		test.Output = splitOutput(tc.Failure.Data)
+		test.Failure.Type = tc.Failure.Type
+		if tc.Failure.Message != "Failed" {
+			test.Failure.Message = tc.Failure.Message
+		}
+		// The location of failed tests refers to the failure, see
+		// createTestcaseForTest.
+		test.Failure.File, test.File = test.File, ""
+		test.Failure.Line, test.Line = test.Line, 0
+	} else if tc.Skipped != nil {
+		test.Result = gtr.Skip
+		test.Output = splitOutput(tc.Skipped.Data)
+	} else if tc.Error != nil {
+		test.Result = gtr.Unknown
+		test.Output = splitOutput(tc.Error.Data)
+	} else {
+		test.Result = gtr.Pass
+		if tc.SystemOut != nil {
+			test.Output = splitOutput(tc.SystemOut.Data)
+		}
+	}
+
+	if tc.SystemErr != nil {
+		test.Stderr = splitOutput(tc.SystemErr.Data)
+	}
+
+	var reruns []Rerun
+	reruns = append(reruns, tc.FlakyFailures...)
+	reruns = append(reruns, tc.RerunFailures...)
+	if len(reruns) > 0 {
+		var attempts []gtr.Test
+		for _, rerun := range reruns {
+			attempts = append(attempts, createTestFromRerun(test, rerun))
+		}
+		test.Attempts = append(attempts, test)
+	}
+	return test
+}
+
+func createTestFromRerun(test gtr.Test, rerun Rerun) gtr.Test {
+	attempt := gtr.NewTest(test.ID, test.Name)
+	attempt.Level = test.Level
+	attempt.Result = gtr.Fail
+	attempt.Failure.Type = rerun.Type
+	if rerun.Message != "Failed" {
+		attempt.Failure.Message = rerun.Message
+	}
+	if rerun.StackTrace != nil {
+		attempt.Output = splitOutput(rerun.StackTrace.Data)
+	}
+	return attempt
+}
+
 // Testsuite is a single JUnit testsuite containing testcases.
 type Testsuite struct {
 	// required attributes
@@ -67,10 +283,11 @@ type Testsuite struct {
 	Skipped   int    `xml:"skipped,attr,omitempty"`
 	Time      string `xml:"time,attr"`                // duration in seconds
 	Timestamp string `xml:"timestamp,attr,omitempty"` // date and time in ISO8601
-	File      string `xml:"file,attr,omitempty"`
+	File      string `xml:"file,attr,omitempty"`      // directory of the test source files
 
 	Properties *[]Property `xml:"properties>property,omitempty"`
 	Testcases  []Testcase  `xml:"testcase,omitempty"`
+	Suites     []Testsuite `xml:"testsuite,omitempty"` // nested testsuites, see NestedSuites
 	SystemOut  *Output     `xml:"system-out,omitempty"`
 	SystemErr  *Output     `xml:"system-err,omitempty"`
 }
@@ -104,6 +321,16 @@ func (t *Testsuite) AddTestcase(tc Testcase) {
 	}
 }
 
+// AddSuite adds a nested Testsuite ts to this Testsuite and updates its totals.
+func (t *Testsuite) AddSuite(ts Testsuite) {
+	t.Suites = append(t.Suites, ts)
+	t.Tests += ts.Tests
+	t.Errors += ts.Errors
+	t.Failures += ts.Failures
+	t.Skipped += ts.Skipped
+	t.Disabled += ts.Disabled
+}
+
 // SetTimestamp sets the timestamp in this Testsuite.
 func (t *Testsuite) SetTimestamp(timestamp time.Time) {
 	t.Timestamp = timestamp.Format(time.RFC3339)
@@ -119,14 +346,35 @@ type Testcase struct {
 	Time      string `xml:"time,attr,omitempty"`      // duration in seconds
 	Timestamp string `xml:"timestamp,attr,omitempty"` // date and time in ISO8601
 	Status    string `xml:"status,attr,omitempty"`
+	File      string `xml:"file,attr,omitempty"` // source file of the test or failure
+	Line      int    `xml:"line,attr,omitempty"` // source line of the test or failure
+
+	Properties *[]Property `xml:"properties>property,omitempty"`
+	Skipped    *Result     `xml:"skipped,omitempty"`
+	Error      *Result     `xml:"error,omitempty"`
+	Failure    *Result     `xml:"failure,omitempty"`
+
+	// Failed attempts of tests that were run more than once, as used by the
+	// Maven Surefire plugin. FlakyFailures are reported for tests that
+	// passed in their last attempt, RerunFailures for tests that did not.
+	FlakyFailures []Rerun `xml:"flakyFailure,omitempty"`
+	RerunFailures []Rerun `xml:"rerunFailure,omitempty"`
 
-	Skipped   *Result `xml:"skipped,omitempty"`
-	Error     *Result `xml:"error,omitempty"`
-	Failure   *Result `xml:"failure,omitempty"`
 	SystemOut *Output `xml:"system-out,omitempty"`
 	SystemErr *Output `xml:"system-err,omitempty"`
 }
 
+// AddProperty adds a property with the given name and value to this Testcase.
+func (t *Testcase) AddProperty(name, value string) {
+	prop := Property{Name: name, Value: value}
+	if t.Properties == nil {
+		t.Properties = &[]Property{prop}
+		return
+	}
+	props := append(*t.Properties, prop)
+	t.Properties = &props
+}
+
 // SetTimestamp sets the timestamp in this Testcase.
 func (t *Testcase) SetTimestamp(timestamp time.Time) {
 	t.Timestamp = timestamp.Format(time.RFC3339)
@@ -145,80 +393,419 @@ type Result struct {
 	Data    string `xml:",cdata"`
 }
 
+// Rerun represents a failed attempt of a test that was run more than once.
+type Rerun struct {
+	Message    string  `xml:"message,attr"`
+	Type       string  `xml:"type,attr,omitempty"`
+	StackTrace *Output `xml:"stackTrace,omitempty"`
+}
+
 // Output represents output written to stdout or sderr.
 type Output struct {
 	Data string `xml:",cdata"`
 }
 
+// Option configures how a gtr.Report is converted by CreateFromReport.
+type Option func(*options)
+
+type options struct {
+	kinds         map[gtr.Kind]bool
+	groupByKind   bool
+	subtestFormat SubtestFormat
+	templates     Templates
+}
+
+// SubtestFormat configures how subtests are represented in the JUnit report.
+type SubtestFormat string
+
+const (
+	// SubtestFormatDefault is the default subtest format. Subtests are
+	// reported as testcases in the package testsuite, like any other test.
+	SubtestFormatDefault SubtestFormat = ""
+
+	// NestedSuites reports tests with subtests as nested testsuites named
+	// after the test, containing a testcase for each of its subtests. The
+	// test itself is only reported as a testcase in the nested testsuite if
+	// it was skipped, did not finish or failed for a reason other than its
+	// failed subtests. The totals of a testsuite include its nested
+	// testsuites.
+	NestedSuites SubtestFormat = "nested"
+
+	// ParentClassname uses the package name followed by the name of the
+	// top-level test as the classname of subtests, e.g. "package/name.TestA",
+	// and the remainder of the subtest name as the testcase name. CI systems
+	// that group testcases by classname will show the subtests of each test
+	// together.
+	ParentClassname SubtestFormat = "parent-classname"
+)
+
+// ParseSubtestFormat returns a SubtestFormat for the given string.
+func ParseSubtestFormat(in string) (SubtestFormat, error) {
+	switch in {
+	case string(NestedSuites):
+		return NestedSuites, nil
+	case string(ParentClassname):
+		return ParentClassname, nil
+	default:
+		return SubtestFormatDefault, fmt.Errorf("unknown subtest format: %v", in)
+	}
+}
+
+// SetSubtestFormat is an Option to change how subtests are represented in the
+// created report. See the documentation for the individual SubtestFormats for
+// more information.
+func SetSubtestFormat(format SubtestFormat) Option {
+	return func(o *options) {
+		o.subtestFormat = format
+	}
+}
+
+// FilterKinds is an Option that only includes tests of the given kinds in the
+// created report.
+func FilterKinds(kinds ...gtr.Kind) Option {
+	return func(o *options) {
+		o.kinds = make(map[gtr.Kind]bool)
+		for _, k := range kinds {
+			o.kinds[k] = true
+		}
+	}
+}
+
+// GroupByKind is an Option that creates a separate testsuite for each kind of
+// test in a package. The testsuites are named after the package followed by
+// the kind in square brackets, e.g. "package/name [benchmark]". Package output
+// and build or runtime errors are added to the first testsuite.
+func GroupByKind() Option {
+	return func(o *options) {
+		o.groupByKind = true
+	}
+}
+
+// Templates contains text/template templates that are used to generate the
+// classname and name of testcases and the name of testsuites. Templates that
+// are nil are not used. See TemplateData for the data that is available to
+// the templates, and ParseTemplates for the available functions.
+//
+// The names of testcases created for build and runtime errors are not
+// changed. Note that ToReport cannot reconstruct the original test names from
+// testcases whose names were changed by a template.
+type Templates struct {
+	Classname *template.Template
+	Name      *template.Template
+	Suite     *template.Template
+}
+
+// TemplateData is the data that is available to Templates.
+type TemplateData struct {
+	Package  string // import path of the package
+	Test     string // full name of the test, e.g. "TestA/case_1/sub"
+	TopLevel string // name of the top-level test, e.g. "TestA"
+	Subtest  string // subtest path below the top-level test, empty for top-level tests
+	Kind     string // kind of test: test, benchmark, example or fuzz
+
+	// Classname and Name contain the classname and name the testcase would
+	// have without templates. For testsuite names, Name contains the default
+	// testsuite name.
+	Classname string
+	Name      string
+}
+
+var templateFuncs = template.FuncMap{
+	"replace":    strings.ReplaceAll,
+	"trimPrefix": strings.TrimPrefix,
+	"trimSuffix": strings.TrimSuffix,
+	"base":       path.Base,
+}
+
+// ParseTemplates parses the given classname, name and testsuite name
+// templates. Empty templates are ignored. In addition to the builtin template
+// functions, the functions replace, trimPrefix and trimSuffix from the strings
+// package and base from the path package are available, e.g.
+// `{{replace .Package "." "_"}}`.
+func ParseTemplates(classname, name, suite string) (Templates, error) {
+	var t Templates
+	var err error
+	if t.Classname, err = parseTemplate("classname", classname); err != nil {
+		return Templates{}, err
+	}
+	if t.Name, err = parseTemplate("name", name); err != nil {
+		return Templates{}, err
+	}
+	if t.Suite, err = parseTemplate("suite", suite); err != nil {
+		return Templates{}, err
+	}
+	return t, nil
+}
+
+func parseTemplate(name, text string) (*template.Template, error) {
+	if text == "" {
+		return nil, nil
+	}
+	tmpl, err := template.New(name).Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	// Catch references to unknown fields before the template is used.
+	if err := tmpl.Execute(ioutil.Discard, TemplateData{}); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// SetTemplates is an Option that uses the given templates to generate the
+// names of testcases and testsuites.
+func SetTemplates(t Templates) Option {
+	return func(o *options) {
+		o.templates = t
+	}
+}
+
+// execute returns the result of executing tmpl with data, or def if tmpl is
+// nil or could not be executed.
+func execute(tmpl *template.Template, data TemplateData, def string) string {
+	if tmpl == nil {
+		return def
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return def
+	}
+	return b.String()
+}
+
+// suiteGroup contains the tests of a package that should be added to a single
+// testsuite.
+type suiteGroup struct {
+	name string
+	kind string // empty unless grouped by kind
+	pkg  gtr.Package
+}
+
+// groups returns the testsuites that should be created for pkg.
+func (o options) groups(pkg gtr.Package) []suiteGroup {
+	var tests []gtr.Test
+	for _, test := range pkg.Tests {
+		if o.kinds == nil || o.kinds[test.Kind] {
+			tests = append(tests, test)
+		}
+	}
+
+	if !o.groupByKind {
+		pkg.Tests = tests
+		return []suiteGroup{{pkg.Name, "", pkg}}
+	}
+
+	var groups []suiteGroup
+	for _, kind := range []gtr.Kind{gtr.KindTest, gtr.KindBenchmark, gtr.KindExample, gtr.KindFuzz} {
+		group := pkg
+		group.Duration = 0
+		group.Tests = nil
+		for _, test := range tests {
+			if test.Kind == kind {
+				group.Tests = append(group.Tests, test)
+			}
+		}
+		if len(group.Tests) == 0 {
+			continue
+		}
+		if len(groups) > 0 {
+			group.Output = nil
+			group.Stderr = nil
+			group.BuildError = gtr.Error{}
+			group.RunError = gtr.Error{}
+		}
+		groups = append(groups, suiteGroup{fmt.Sprintf("%s [%s]", pkg.Name, kind), kind.String(), group})
+	}
+	if len(groups) == 0 {
+		pkg.Tests = nil
+		return []suiteGroup{{pkg.Name, "", pkg}}
+	}
+	return groups
+}
+
 // CreateFromReport creates a JUnit representation of the given gtr.Report.
-func CreateFromReport(report gtr.Report, hostname string) Testsuites {
+func CreateFromReport(report gtr.Report, hostname string, opts ...Option) Testsuites {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	var suites Testsuites
-	for _, pkg := range report.Packages {
-		var duration time.Duration
-		suite := Testsuite{
-			Name:     pkg.Name,
-			Hostname: hostname,
-			ID:       len(suites.Suites),
+	for _, p := range report.Packages {
+		for _, group := range o.groups(p) {
+			data := TemplateData{Package: p.Name, Kind: group.kind, Name: group.name}
+			name := execute(o.templates.Suite, data, group.name)
+			suites.AddSuite(o.createSuite(name, group.pkg, hostname, len(suites.Suites)))
 		}
+	}
+	return suites
+}
+
+// createSuite creates a Testsuite with the given name for the tests in pkg.
+func (o options) createSuite(name string, pkg gtr.Package, hostname string, id int) Testsuite {
+	var duration time.Duration
+	suite := Testsuite{
+		Name:     name,
+		Hostname: hostname,
+		ID:       id,
+	}
+
+	if !pkg.Timestamp.IsZero() {
+		suite.SetTimestamp(pkg.Timestamp)
+	}
 
-		if !pkg.Timestamp.IsZero() {
-			suite.SetTimestamp(pkg.Timestamp)
+	for _, p := range pkg.Properties {
+		suite.AddProperty(p.Name, p.Value)
+	}
+
+	if len(pkg.Output) > 0 {
+		suite.SystemOut = &Output{Data: formatOutput(pkg.Output)}
+	}
+	if len(pkg.Stderr) > 0 {
+		suite.SystemErr = &Output{Data: formatOutput(pkg.Stderr)}
+	}
+
+	if pkg.Coverage > 0 || pkg.HasCoverage {
+		suite.AddProperty("coverage.statements.pct", fmt.Sprintf("%.2f", pkg.Coverage))
+	}
+
+	for _, test := range pkg.Tests {
+		duration += test.Duration
+		if suite.File == "" && test.File != "" {
+			suite.File = path.Dir(test.File)
+		}
+	}
+	if o.subtestFormat == NestedSuites {
+		for _, node := range pkg.TestTree() {
+			o.addTestNode(&suite, pkg.Name, node)
 		}
+	} else {
+		for _, test := range pkg.Tests {
+			suite.AddTestcase(o.createTestcase(pkg.Name, test))
+		}
+	}
 
-		for _, p := range pkg.Properties {
-			suite.AddProperty(p.Name, p.Value)
+	// JUnit doesn't have a good way of dealing with build or runtime
+	// errors that happen before a test has started, so we create a single
+	// failing test that contains the build error details.
+	if pkg.BuildError.Name != "" {
+		tc := Testcase{
+			Classname: pkg.BuildError.Name,
+			Name:      pkg.BuildError.Cause,
+			Time:      formatDuration(0),
+			Error: &Result{
+				Message: "Build error",
+				Data:    strings.Join(pkg.BuildError.Output, "\n"),
+			},
 		}
+		suite.AddTestcase(tc)
+	}
 
-		if len(pkg.Output) > 0 {
-			suite.SystemOut = &Output{Data: formatOutput(pkg.Output)}
+	if pkg.RunError.Name != "" {
+		tc := Testcase{
+			Classname: pkg.RunError.Name,
+			Name:      "Failure",
+			Time:      formatDuration(0),
+			Error: &Result{
+				Message: "Runtime error",
+				Data:    strings.Join(pkg.RunError.Output, "\n"),
+			},
 		}
+		suite.AddTestcase(tc)
+	}
+
+	if (pkg.Duration) == 0 {
+		suite.Time = formatDuration(duration)
+	} else {
+		suite.Time = formatDuration(pkg.Duration)
+	}
+	return suite
+}
 
-		if pkg.Coverage > 0 {
-			suite.AddProperty("coverage.statements.pct", fmt.Sprintf("%.2f", pkg.Coverage))
+// addTestNode adds the test in node to suite. Tests with subtests are added as
+// a nested testsuite, see NestedSuites.
+func (o options) addTestNode(suite *Testsuite, pkgName string, node *gtr.TestNode) {
+	test := node.Test
+	if len(node.Children) == 0 {
+		suite.AddTestcase(o.createTestcase(pkgName, test))
+		return
+	}
+
+	// Nested testsuites are named after their test, so that they can be told
+	// apart. The suite name template only applies to package testsuites.
+	nested := Testsuite{
+		Name: test.Name,
+		ID:   test.ID,
+		Time: formatDuration(test.Duration),
+	}
+	if includeParentTestcase(node) {
+		nested.AddTestcase(o.createTestcase(pkgName, test))
+	} else {
+		for _, p := range test.Properties {
+			nested.AddProperty(p.Name, p.Value)
+		}
+		if len(test.Output) > 0 {
+			nested.SystemOut = &Output{Data: formatOutput(test.Output)}
 		}
+		if len(test.Stderr) > 0 {
+			nested.SystemErr = &Output{Data: formatOutput(test.Stderr)}
+		}
+	}
+	for _, child := range node.Children {
+		o.addTestNode(&nested, pkgName, child)
+	}
+	suite.AddSuite(nested)
+}
 
-		for _, test := range pkg.Tests {
-			duration += test.Duration
-			suite.AddTestcase(createTestcaseForTest(pkg.Name, test))
-		}
-
-		// JUnit doesn't have a good way of dealing with build or runtime
-		// errors that happen before a test has started, so we create a single
-		// failing test that contains the build error details.
-		if pkg.BuildError.Name != "" {
-			tc := Testcase{
-				Classname: pkg.BuildError.Name,
-				Name:      pkg.BuildError.Cause,
-				Time:      formatDuration(0),
-				Error: &Result{
-					Message: "Build error",
-					Data:    strings.Join(pkg.BuildError.Output, "\n"),
-				},
-			}
-			suite.AddTestcase(tc)
-		}
-
-		if pkg.RunError.Name != "" {
-			tc := Testcase{
-				Classname: pkg.RunError.Name,
-				Name:      "Failure",
-				Time:      formatDuration(0),
-				Error: &Result{
-					Message: "Runtime error",
-					Data:    strings.Join(pkg.RunError.Output, "\n"),
-				},
+// includeParentTestcase returns true if the test in node should be reported
+// as a testcase in its nested testsuite, i.e. when its result is not fully
+// explained by the results of its subtests.
+func includeParentTestcase(node *gtr.TestNode) bool {
+	test := node.Test
+	switch test.Result {
+	case gtr.Pass:
+		return false
+	case gtr.Fail:
+		if test.Failure != (gtr.Failure{}) {
+			return true
+		}
+		for _, child := range node.Children {
+			if child.Result() == gtr.Fail {
+				return false
 			}
-			suite.AddTestcase(tc)
 		}
+		return true
+	default:
+		return true
+	}
+}
 
-		if (pkg.Duration) == 0 {
-			suite.Time = formatDuration(duration)
-		} else {
-			suite.Time = formatDuration(pkg.Duration)
+// createTestcase creates a Testcase for test, using the configured subtest
+// format and templates.
+func (o options) createTestcase(pkgName string, test gtr.Test) Testcase {
+	tc := createTestcaseForTest(pkgName, test)
+	parts := strings.SplitN(test.Name, "/", 2)
+	if o.subtestFormat == ParentClassname && len(parts) == 2 {
+		tc.Classname = pkgName + "." + parts[0]
+		tc.Name = parts[1]
+	}
+
+	if o.templates.Classname != nil || o.templates.Name != nil {
+		data := TemplateData{
+			Package:   pkgName,
+			Test:      test.Name,
+			TopLevel:  parts[0],
+			Kind:      test.Kind.String(),
+			Classname: tc.Classname,
+			Name:      tc.Name,
+		}
+		if len(parts) == 2 {
+			data.Subtest = parts[1]
 		}
-		suites.AddSuite(suite)
+		tc.Classname = execute(o.templates.Classname, data, tc.Classname)
+		tc.Name = execute(o.templates.Name, data, tc.Name)
 	}
-	return suites
+	return tc
 }
 
 func createTestcaseForTest(pkgName string, test gtr.Test) Testcase {
@@ -226,13 +813,28 @@ func createTestcaseForTest(pkgName string, test gtr.Test) Testcase {
 		Classname: pkgName,
 		Name:      test.Name,
 		Time:      formatDuration(test.Duration),
+		File:      test.File,
+		Line:      test.Line,
+	}
+
+	for _, p := range test.Properties {
+		tc.AddProperty(p.Name, p.Value)
 	}
 
 	if test.Result == gtr.Fail {
 		tc.Failure = &Result{
 			Message: "Failed",
+			Type:    test.Failure.Type,
 			Data:    formatOutput(test.Output),
 		}
+		if test.Failure.Message != "" {
+			tc.Failure.Message = test.Failure.Message
+		}
+		// Point failed tests to the location of the failure instead of the
+		// test declaration, if known.
+		if test.Failure.File != "" {
+			tc.File, tc.Line = test.Failure.File, test.Failure.Line
+		}
 	} else if test.Result == gtr.Skip {
 		tc.Skipped = &Result{
 			Message: "Skipped",
@@ -246,15 +848,60 @@ func createTestcaseForTest(pkgName string, test gtr.Test) Testcase {
 	} else if len(test.Output) > 0 {
 		tc.SystemOut = &Output{Data: formatOutput(test.Output)}
 	}
+	if len(test.Stderr) > 0 {
+		tc.SystemErr = &Output{Data: formatOutput(test.Stderr)}
+	}
+
+	for i, attempt := range test.Attempts {
+		if attempt.Result != gtr.Fail {
+			continue
+		}
+		if test.Result == gtr.Pass {
+			tc.FlakyFailures = append(tc.FlakyFailures, createRerun(attempt))
+		} else if test.Result == gtr.Fail && i < len(test.Attempts)-1 {
+			// The last attempt is reported as the failure itself.
+			tc.RerunFailures = append(tc.RerunFailures, createRerun(attempt))
+		}
+	}
 	return tc
 }
 
+func createRerun(attempt gtr.Test) Rerun {
+	rerun := Rerun{
+		Message: "Failed",
+		Type:    attempt.Failure.Type,
+	}
+	if attempt.Failure.Message != "" {
+		rerun.Message = attempt.Failure.Message
+	}
+	if len(attempt.Output) > 0 {
+		rerun.StackTrace = &Output{Data: formatOutput(attempt.Output)}
+	}
+	return rerun
+}
+
 // formatDuration returns the JUnit string representation of the given
 // duration.
 func formatDuration(d time.Duration) string {
 	return fmt.Sprintf("%.3f", d.Seconds())
 }
 
+// parseDuration returns the duration represented by the given JUnit string
+// representation in seconds.
+func parseDuration(s string) time.Duration {
+	// ignore error
+	seconds, _ := strconv.ParseFloat(s, 64)
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// splitOutput splits the given output into separate lines.
+func splitOutput(output string) []string {
+	if output == "" {
+		return nil
+	}
+	return strings.Split(output, "\n")
+}
+
 // formatOutput combines the lines from the given output into a single string.
 func formatOutput(output []string) string {
 	return escapeIllegalChars(strings.Join(output, "\n"))
@@ -3,6 +3,7 @@ package junit
 import (
 	"bytes"
 	"encoding/xml"
+	"strings"
 	"testing"
 	"time"
 
@@ -26,6 +27,8 @@ func TestCreateFromReport(t *testing.T) {
 						Name:   "TestPass",
 						Result: gtr.Pass,
 						Output: []string{"ok"},
+						File:   "name/pass_test.go",
+						Line:   5,
 					},
 					{
 						Name:   "TestEscapeOutput",
@@ -37,6 +40,12 @@ func TestCreateFromReport(t *testing.T) {
 						Result: gtr.Fail,
 						Output: []string{"fail"},
 					},
+					{
+						Name:    "TestFailLocation",
+						Result:  gtr.Fail,
+						Output:  []string{"    fail_test.go:10: message"},
+						Failure: gtr.Failure{Message: "message", File: "fail_test.go", Line: 10},
+					},
 					{
 						Name:   "TestSkip",
 						Result: gtr.Skip,
@@ -45,6 +54,11 @@ func TestCreateFromReport(t *testing.T) {
 						Name:   "TestIncomplete",
 						Result: gtr.Unknown,
 					},
+					{
+						Name:       "BenchmarkProperties",
+						Result:     gtr.Pass,
+						Properties: []gtr.Property{{Name: "ns/op", Value: "604"}},
+					},
 				},
 				BuildError: gtr.Error{Name: "Build error"},
 				RunError:   gtr.Error{Name: "Run error"},
@@ -53,20 +67,21 @@ func TestCreateFromReport(t *testing.T) {
 	}
 
 	want := Testsuites{
-		Tests:    7,
+		Tests:    9,
 		Errors:   3,
-		Failures: 1,
+		Failures: 2,
 		Skipped:  1,
 		Suites: []Testsuite{
 			{
 				Name:      "package/name",
-				Tests:     7,
+				Tests:     9,
 				Errors:    3,
 				ID:        0,
-				Failures:  1,
+				Failures:  2,
 				Skipped:   1,
 				Time:      "1.000",
 				Timestamp: "2022-06-26T00:00:00Z",
+				File:      "name",
 				Properties: &[]Property{
 					{Name: "go.version", Value: "go1.18"},
 					{Name: "coverage.statements.pct", Value: "0.90"},
@@ -76,6 +91,8 @@ func TestCreateFromReport(t *testing.T) {
 						Name:      "TestPass",
 						Classname: "package/name",
 						Time:      "0.000",
+						File:      "name/pass_test.go",
+						Line:      5,
 						SystemOut: &Output{Data: "ok"},
 					},
 					{
@@ -90,6 +107,14 @@ func TestCreateFromReport(t *testing.T) {
 						Time:      "0.000",
 						Failure:   &Result{Message: "Failed", Data: "fail"},
 					},
+					{
+						Name:      "TestFailLocation",
+						Classname: "package/name",
+						Time:      "0.000",
+						File:      "fail_test.go",
+						Line:      10,
+						Failure:   &Result{Message: "message", Data: "    fail_test.go:10: message"},
+					},
 					{
 						Name:      "TestSkip",
 						Classname: "package/name",
@@ -102,6 +127,12 @@ func TestCreateFromReport(t *testing.T) {
 						Time:      "0.000",
 						Error:     &Result{Message: "No test result found"},
 					},
+					{
+						Name:       "BenchmarkProperties",
+						Classname:  "package/name",
+						Time:       "0.000",
+						Properties: &[]Property{{Name: "ns/op", Value: "604"}},
+					},
 					{
 						Classname: "Build error",
 						Time:      "0.000",
@@ -125,6 +156,275 @@ func TestCreateFromReport(t *testing.T) {
 	}
 }
 
+func TestCreateFromReportOptions(t *testing.T) {
+	report := gtr.Report{
+		Packages: []gtr.Package{
+			{
+				Name: "package/name",
+				Tests: []gtr.Test{
+					gtr.NewTest(1, "TestOne"),
+					gtr.NewTest(2, "BenchmarkOne"),
+					gtr.NewTest(3, "ExampleOne"),
+					gtr.NewTest(4, "FuzzOne"),
+				},
+				Output: []string{"output"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name  string
+		opts  []Option
+		want  []string
+		tests []int
+	}{
+		{"none", nil, []string{"package/name"}, []int{4}},
+		{"filter", []Option{FilterKinds(gtr.KindTest, gtr.KindExample)}, []string{"package/name"}, []int{2}},
+		{"group", []Option{GroupByKind()}, []string{"package/name [test]", "package/name [benchmark]", "package/name [example]", "package/name [fuzz]"}, []int{1, 1, 1, 1}},
+		{"filter and group", []Option{FilterKinds(gtr.KindFuzz), GroupByKind()}, []string{"package/name [fuzz]"}, []int{1}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := CreateFromReport(report, "", test.opts...)
+			var names []string
+			var counts []int
+			for _, suite := range got.Suites {
+				names = append(names, suite.Name)
+				counts = append(counts, suite.Tests)
+			}
+			if diff := cmp.Diff(test.want, names); diff != "" {
+				t.Errorf("CreateFromReport testsuite names incorrect, diff (-want, +got):\n%s\n", diff)
+			}
+			if diff := cmp.Diff(test.tests, counts); diff != "" {
+				t.Errorf("CreateFromReport testsuite test counts incorrect, diff (-want, +got):\n%s\n", diff)
+			}
+			if got.Suites[0].SystemOut == nil {
+				t.Errorf("CreateFromReport did not add package output to first testsuite")
+			}
+		})
+	}
+}
+
+func TestAttempts(t *testing.T) {
+	failed := gtr.NewTest(1, "TestFlaky")
+	failed.Result = gtr.Fail
+	failed.Output = []string{"unlucky"}
+	failed.Failure.Message = "unlucky"
+
+	passed := gtr.NewTest(1, "TestFlaky")
+	passed.Result = gtr.Pass
+
+	flaky := passed
+	flaky.Attempts = []gtr.Test{failed, passed}
+
+	report := gtr.Report{Packages: []gtr.Package{{Name: "package/name", Tests: []gtr.Test{flaky}}}}
+
+	suites := CreateFromReport(report, "")
+	wantTestcase := Testcase{
+		Name:          "TestFlaky",
+		Classname:     "package/name",
+		Time:          "0.000",
+		FlakyFailures: []Rerun{{Message: "unlucky", StackTrace: &Output{Data: "unlucky"}}},
+	}
+	if diff := cmp.Diff(wantTestcase, suites.Suites[0].Testcases[0]); diff != "" {
+		t.Errorf("CreateFromReport incorrect, diff (-want, +got):\n%s\n", diff)
+	}
+	if suites.Failures != 0 {
+		t.Errorf("CreateFromReport counted %d failures for flaky test, want 0", suites.Failures)
+	}
+
+	got := suites.ToReport()
+	if diff := cmp.Diff(report, got); diff != "" {
+		t.Errorf("ToReport incorrect, diff (-want, +got):\n%s\n", diff)
+	}
+}
+
+func TestSubtestFormats(t *testing.T) {
+	newTest := func(id, parentID int, name string, result gtr.Result) gtr.Test {
+		test := gtr.NewTest(id, name)
+		test.ParentID = parentID
+		test.Result = result
+		return test
+	}
+	report := gtr.Report{
+		Packages: []gtr.Package{
+			{
+				Name: "package/name",
+				Tests: []gtr.Test{
+					newTest(1, 0, "TestA", gtr.Fail),
+					newTest(2, 1, "TestA/case_1", gtr.Pass),
+					newTest(3, 1, "TestA/case_2", gtr.Fail),
+					newTest(4, 3, "TestA/case_2/sub", gtr.Fail),
+					newTest(5, 0, "TestB", gtr.Pass),
+				},
+			},
+		},
+	}
+
+	type testcase struct{ Classname, Name string }
+	tests := []struct {
+		format        SubtestFormat
+		wantTests     int
+		wantFailures  int
+		wantTestcases []testcase // testcases of the first testsuite
+		wantNested    []string   // names of nested testsuites
+	}{
+		{
+			SubtestFormatDefault, 5, 3,
+			[]testcase{
+				{"package/name", "TestA"},
+				{"package/name", "TestA/case_1"},
+				{"package/name", "TestA/case_2"},
+				{"package/name", "TestA/case_2/sub"},
+				{"package/name", "TestB"},
+			},
+			nil,
+		},
+		{
+			NestedSuites, 3, 1,
+			[]testcase{{"package/name", "TestB"}},
+			[]string{"TestA"},
+		},
+		{
+			ParentClassname, 5, 3,
+			[]testcase{
+				{"package/name", "TestA"},
+				{"package/name.TestA", "case_1"},
+				{"package/name.TestA", "case_2"},
+				{"package/name.TestA", "case_2/sub"},
+				{"package/name", "TestB"},
+			},
+			nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.format), func(t *testing.T) {
+			suites := CreateFromReport(report, "", SetSubtestFormat(test.format))
+			if suites.Tests != test.wantTests || suites.Failures != test.wantFailures {
+				t.Errorf("CreateFromReport totals incorrect, got %d tests and %d failures, want %d tests and %d failures",
+					suites.Tests, suites.Failures, test.wantTests, test.wantFailures)
+			}
+
+			suite := suites.Suites[0]
+			var testcases []testcase
+			for _, tc := range suite.Testcases {
+				testcases = append(testcases, testcase{tc.Classname, tc.Name})
+			}
+			if diff := cmp.Diff(test.wantTestcases, testcases); diff != "" {
+				t.Errorf("CreateFromReport testcases incorrect, diff (-want, +got):\n%s\n", diff)
+			}
+			var nested []string
+			for _, s := range suite.Suites {
+				nested = append(nested, s.Name)
+			}
+			if diff := cmp.Diff(test.wantNested, nested); diff != "" {
+				t.Errorf("CreateFromReport nested testsuites incorrect, diff (-want, +got):\n%s\n", diff)
+			}
+
+			results := make(map[string]gtr.Result)
+			for _, test := range suites.ToReport().Packages[0].Tests {
+				results[test.Name] = test.Result
+			}
+			wantResults := make(map[string]gtr.Result)
+			for _, test := range report.Packages[0].Tests {
+				wantResults[test.Name] = test.Result
+			}
+			if diff := cmp.Diff(wantResults, results); diff != "" {
+				t.Errorf("ToReport test results incorrect, diff (-want, +got):\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestNestedSuitesParentFailure(t *testing.T) {
+	parent := gtr.NewTest(1, "TestA")
+	parent.Result = gtr.Fail
+	parent.Output = []string{"cleanup failed"}
+	sub := gtr.NewTest(2, "TestA/sub")
+	sub.ParentID = 1
+	sub.Result = gtr.Pass
+
+	report := gtr.Report{Packages: []gtr.Package{{Name: "package/name", Tests: []gtr.Test{parent, sub}}}}
+	suites := CreateFromReport(report, "", SetSubtestFormat(NestedSuites))
+
+	nested := suites.Suites[0].Suites[0]
+	if len(nested.Testcases) != 2 || nested.Testcases[0].Failure == nil {
+		t.Fatalf("CreateFromReport did not report failed parent as testcase: %+v", nested.Testcases)
+	}
+	if suites.Tests != 2 || suites.Failures != 1 {
+		t.Errorf("CreateFromReport totals incorrect, got %d tests and %d failures, want 2 tests and 1 failure", suites.Tests, suites.Failures)
+	}
+}
+
+func TestTemplates(t *testing.T) {
+	report := gtr.Report{
+		Packages: []gtr.Package{
+			{
+				Name: "example.com/pkg",
+				Tests: []gtr.Test{
+					gtr.NewTest(1, "TestA"),
+					gtr.NewTest(2, "TestA/case_1/sub"),
+					gtr.NewTest(3, "BenchmarkB"),
+				},
+				BuildError: gtr.Error{Name: "example.com/pkg", Cause: "[build failed]"},
+			},
+		},
+	}
+
+	templates, err := ParseTemplates(
+		`{{replace .Package "." "_"}}.{{.TopLevel}}`,
+		`{{if .Subtest}}{{.Subtest}}{{else}}{{.Name}}{{end}} ({{.Kind}})`,
+		`{{base .Package}}`,
+	)
+	if err != nil {
+		t.Fatalf("ParseTemplates returned error: %v", err)
+	}
+
+	suites := CreateFromReport(report, "", SetTemplates(templates))
+	suite := suites.Suites[0]
+	if suite.Name != "pkg" {
+		t.Errorf("CreateFromReport testsuite name = %q, want %q", suite.Name, "pkg")
+	}
+
+	type testcase struct{ Classname, Name string }
+	want := []testcase{
+		{"example_com/pkg.TestA", "TestA (test)"},
+		{"example_com/pkg.TestA", "case_1/sub (test)"},
+		{"example_com/pkg.BenchmarkB", "BenchmarkB (benchmark)"},
+		{"example.com/pkg", "[build failed]"},
+	}
+	var got []testcase
+	for _, tc := range suite.Testcases {
+		got = append(got, testcase{tc.Classname, tc.Name})
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("CreateFromReport testcases incorrect, diff (-want, +got):\n%s\n", diff)
+	}
+
+	report.Packages[0].Tests[1].ParentID = 1
+	suites = CreateFromReport(report, "", SetTemplates(templates), SetSubtestFormat(NestedSuites))
+	var gotSuites []string
+	for _, s := range suites.Suites {
+		gotSuites = append(gotSuites, s.Name)
+		for _, nested := range s.Suites {
+			gotSuites = append(gotSuites, nested.Name)
+		}
+	}
+	if diff := cmp.Diff([]string{"pkg", "TestA"}, gotSuites); diff != "" {
+		t.Errorf("CreateFromReport testsuite names incorrect, diff (-want, +got):\n%s\n", diff)
+	}
+}
+
+func TestParseTemplatesError(t *testing.T) {
+	for _, tmpl := range []string{"{{.Package", "{{.Unknown}}", "{{unknown .Package}}"} {
+		if _, err := ParseTemplates(tmpl, "", ""); err == nil {
+			t.Errorf("ParseTemplates(%q) did not return an error", tmpl)
+		}
+	}
+}
+
 func TestMarshalUnmarshal(t *testing.T) {
 	want := Testsuites{
 		Name:     "name",
@@ -208,3 +508,93 @@ func TestWriteXML(t *testing.T) {
 		t.Errorf("WriteXML mismatch, diff (-want +got):\n%s\n", diff)
 	}
 }
+
+func TestReadXML(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  Testsuites
+	}{
+		{
+			"testsuites",
+			`<?xml version="1.0" encoding="UTF-8"?>
+<testsuites tests="1">
+	<testsuite name="package/name" tests="1" failures="0" errors="0" id="0" time="0.100">
+		<testcase name="TestOne" classname="package/name" time="0.100"></testcase>
+	</testsuite>
+</testsuites>`,
+			Testsuites{
+				XMLName: xml.Name{Local: "testsuites"},
+				Tests:   1,
+				Suites: []Testsuite{{
+					Name:      "package/name",
+					Tests:     1,
+					Time:      "0.100",
+					Testcases: []Testcase{{Name: "TestOne", Classname: "package/name", Time: "0.100"}},
+				}},
+			},
+		},
+		{
+			"single testsuite",
+			`<testsuite name="package/name" tests="1" failures="0" errors="0" id="0" time="0.100">
+	<testcase name="TestOne" classname="package/name" time="0.100"></testcase>
+</testsuite>`,
+			Testsuites{
+				Tests: 1,
+				Suites: []Testsuite{{
+					Name:      "package/name",
+					Tests:     1,
+					Time:      "0.100",
+					Testcases: []Testcase{{Name: "TestOne", Classname: "package/name", Time: "0.100"}},
+				}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ReadXML(strings.NewReader(test.input))
+			if err != nil {
+				t.Fatalf("ReadXML failed: %v", err)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("ReadXML result incorrect, diff (-want +got):\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestToReport(t *testing.T) {
+	report := gtr.Report{
+		Packages: []gtr.Package{
+			{
+				Name:        "package/name",
+				Timestamp:   time.Date(2022, 6, 26, 0, 0, 0, 0, time.UTC),
+				Duration:    1 * time.Second,
+				Coverage:    0.9,
+				HasCoverage: true,
+				Output:      []string{"output"},
+				Stderr:      []string{"stderr"},
+				Properties:  []gtr.Property{{Name: "go.version", Value: "go1.18"}},
+				Tests: []gtr.Test{
+					{ID: 1, Name: "TestPass", Result: gtr.Pass, Duration: 100 * time.Millisecond, Output: []string{"ok"}, Stderr: []string{"log"}, File: "name/pass_test.go", Line: 5},
+					{ID: 2, ParentID: 1, Name: "TestPass/sub", Result: gtr.Pass, Level: 1},
+					{ID: 3, Name: "TestFail", Result: gtr.Fail, Output: []string{"fail", "here"}, Failure: gtr.Failure{Type: "panic", Message: "oops", File: "fail_test.go", Line: 12}},
+					{ID: 4, Name: "TestSkip", Result: gtr.Skip},
+					{ID: 5, Name: "TestIncomplete", Result: gtr.Unknown},
+					{ID: 6, Name: "BenchmarkOne", Kind: gtr.KindBenchmark, Result: gtr.Pass, Properties: []gtr.Property{{Name: "ns/op", Value: "604"}}},
+				},
+				BuildError: gtr.Error{ID: 7, Name: "Build error", Cause: "[build failed]", Output: []string{"build output"}},
+				RunError:   gtr.Error{ID: 8, Name: "Run error", Output: []string{"run output"}},
+			},
+		},
+	}
+	for i := range report.Packages[0].Tests {
+		report.Packages[0].Tests[i].Data = map[string]interface{}{}
+	}
+
+	got := CreateFromReport(report, "").ToReport()
+	if diff := cmp.Diff(report, got); diff != "" {
+		t.Errorf("ToReport result incorrect, diff (-want +got):\n%s\n", diff)
+	}
+}
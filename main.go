@@ -8,9 +8,14 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"os/exec"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/jstemmer/go-junit-report/v2/gtr"
 	"github.com/jstemmer/go-junit-report/v2/internal/gojunitreport"
+	"github.com/jstemmer/go-junit-report/v2/junit"
 	"github.com/jstemmer/go-junit-report/v2/parser/gotest"
 )
 
@@ -31,7 +36,55 @@ var (
 	iocopy      = flag.Bool("iocopy", false, "copy input to stdout; can only be used in conjunction with -out")
 	properties  = make(keyValueFlag)
 	parser      = flag.String("parser", "gotest", "set input parser: gotest, gojson")
+	format      = flag.String("format", "junit", "set output `format`: junit, json")
 	mode        = flag.String("subtest-mode", "", "set subtest `mode`: ignore-parent-results (subtest parents always pass), exclude-parents (subtest parents are excluded from the report)")
+	subtestFmt  = flag.String("subtest-format", "", "set subtest `format`: nested (tests with subtests become nested testsuites), parent-classname (subtests use the package and parent test as classname)")
+	kinds       = flag.String("kinds", "", "only include tests of the given comma separated `kinds`: test, benchmark, example, fuzz")
+	quarantine  = flag.String("quarantine", "", "read quarantined tests from JSON `file`; failures of quarantined tests are reported as skipped")
+	attempts    = flag.Bool("group-attempts", false, "report tests that ran more than once as a single test with multiple attempts; tests that failed and then passed are reported as flaky")
+	groupByKind = flag.Bool("group-by-kind", false, "create a separate testsuite for each kind of test in a package")
+	moduleRoot  = flag.String("module-root", "", "resolve test source files and line numbers from the Go module in `dir`")
+
+	classnameTemplate = flag.String("classname-template", "", "generate testcase classnames using the text/template `template`, e.g. {{.Package}}.{{.TopLevel}}")
+	nameTemplate      = flag.String("name-template", "", "generate testcase names using the text/template `template`, e.g. {{replace .Name \"/\" \" > \"}}")
+	suiteNameTemplate = flag.String("suite-name-template", "", "generate package testsuite names using the text/template `template`, e.g. {{trimPrefix .Name \"example.com/\"}}")
+
+	// output limit flags
+	maxTestOutput    byteSize
+	maxPackageOutput byteSize
+	maxTotalOutput   byteSize
+	truncatePolicy   = flag.String("truncate-policy", string(gojunitreport.TruncateHeadTail), "set the `policy` for which lines to keep when output exceeds a limit: head, tail, head-tail")
+
+	// memory flags
+	spillOutput = flag.Bool("spill-output", false, "store captured test output in a temporary file while parsing, and truncate the output of each package as soon as it has been parsed")
+	spillDir    = flag.String("spill-dir", "", "create the temporary file for -spill-output in `dir`")
+
+	// reproducibility flags
+	timestamp    = flag.String("timestamp", "", "use `time`, in RFC 3339 format or as seconds since the Unix epoch, as the timestamp of all testsuites")
+	reproducible = flag.Bool("reproducible", false, "generate a reproducible report: omit the hostname and use the -timestamp or $SOURCE_DATE_EPOCH as timestamp, or omit timestamps if neither is set")
+
+	// coverage flags
+	minCoverage        = flag.Float64("min-coverage", 0, "minimum statement coverage of each package in `percent`")
+	minTotalCoverage   = flag.Float64("min-total-coverage", 0, "minimum total statement coverage of all packages in `percent`")
+	coverageThresholds = flag.String("coverage-thresholds", "", "read the minimum coverage of specific packages from JSON `file`, overriding -min-coverage")
+	coverProfiles      = flag.String("coverprofile", "", "read and merge the comma separated coverage profile `files` written by go test -coverprofile")
+	coberturaOutput    = flag.String("cobertura", "", "write a Cobertura XML coverage report for the -coverprofile files to `file`")
+	coverageFailure    = flag.String("coverage-failure", "exit", "how to report packages below their minimum coverage: exit (exit with code 1), testcase (add a failed testcase)")
+
+	// rerun flags
+	rerunFile   = flag.String("rerun-file", "", "write `go test` commands that rerun only the failed tests to `file`")
+	rerunFormat = flag.String("rerun-format", "sh", "set the `format` of the -rerun-file: sh (one command per line), json")
+
+	// redaction flags
+	redact      = flag.Bool("redact", false, "replace common kinds of secrets, such as access keys and tokens, in the test output with ***")
+	redactRules = flag.String("redact-rules", "", "replace matches of the regular expressions in `file`, one per line, in the test output with ***")
+	redactEnv   = flag.String("redact-env", "", "replace the values of the comma separated environment variable `names` in the test output with ***")
+
+	// benchmark flags
+	benchBaseline        = flag.String("bench-baseline", "", "compare benchmarks to the results in the given JUnit report or go test log `file` and fail benchmarks that regressed")
+	benchThresholdNs     = flag.Float64("bench-threshold-ns", gojunitreport.DefaultBenchmarkThresholds.NsPerOp, "maximum relative increase of ns/op compared to the -bench-baseline, negative to disable")
+	benchThresholdBytes  = flag.Float64("bench-threshold-bytes", gojunitreport.DefaultBenchmarkThresholds.BytesPerOp, "maximum relative increase of B/op compared to the -bench-baseline, negative to disable")
+	benchThresholdAllocs = flag.Float64("bench-threshold-allocs", gojunitreport.DefaultBenchmarkThresholds.AllocsPerOp, "maximum relative increase of allocs/op compared to the -bench-baseline, negative to disable")
 
 	// debug flags
 	printEvents = flag.Bool("debug.print-events", false, "print events generated by the go test parser")
@@ -40,8 +93,25 @@ var (
 	goVersionFlag = flag.String("go-version", "", "(deprecated, use -prop) the value to use for the go.version property in the generated XML")
 )
 
+// subcommands contains the commands that can be run using
+// `go-junit-report <command> [flags]`.
+var subcommands = map[string]func(args []string){
+	"diff":  runDiff,
+	"flaky": runFlaky,
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		if cmd, ok := subcommands[os.Args[1]]; ok {
+			cmd(os.Args[2:])
+			return
+		}
+	}
+
 	flag.Var(&properties, "p", "add `key=value` property to generated report; repeat this flag to add multiple properties.")
+	flag.Var(&maxTestOutput, "max-test-output", "truncate the output of each test to at most `size` bytes, e.g. 64KB")
+	flag.Var(&maxPackageOutput, "max-package-output", "truncate the output of each package and its tests to at most `size` bytes, e.g. 10MB")
+	flag.Var(&maxTotalOutput, "max-output", "truncate all output in the report to at most `size` bytes, e.g. 100MB")
 	flag.Parse()
 
 	if *iocopy && *output == "" {
@@ -66,15 +136,117 @@ func main() {
 		}
 	}
 
-	if flag.NArg() != 0 {
+	subtestFormat := junit.SubtestFormatDefault
+	if *subtestFmt != "" {
+		var err error
+		if subtestFormat, err = junit.ParseSubtestFormat(*subtestFmt); err != nil {
+			exitf("invalid value for -subtest-format: %s\n", err)
+		}
+	}
+
+	policy, err := gojunitreport.ParseTruncatePolicy(*truncatePolicy)
+	if err != nil {
+		exitf("invalid value for -truncate-policy: %s\n", err)
+	}
+
+	timestampFunc, err := newTimestampFunc()
+	if err != nil {
+		exitf("invalid value for -timestamp: %s\n", err)
+	}
+
+	if *rerunFormat != "sh" && *rerunFormat != "json" {
+		exitf("invalid value for -rerun-format: %s\n", *rerunFormat)
+	}
+
+	var testKinds []gtr.Kind
+	if *kinds != "" {
+		for _, s := range strings.Split(*kinds, ",") {
+			kind, err := gtr.ParseKind(strings.TrimSpace(s))
+			if err != nil {
+				exitf("invalid value for -kinds: %s\n", err)
+			}
+			testKinds = append(testKinds, kind)
+		}
+	}
+
+	// Arguments after "--" are a command to run, whose output is used as
+	// input.
+	var command []string
+	if flag.NArg() != 0 && os.Args[len(os.Args)-flag.NArg()-1] == "--" {
+		command = flag.Args()
+		if *input != "" {
+			exitf("-in cannot be used when running a command")
+		}
+	} else if flag.NArg() != 0 {
 		fmt.Fprintf(os.Stderr, "invalid argument(s): %s\n", strings.Join(flag.Args(), " "))
 		fmt.Fprintf(os.Stderr, "%s does not accept positional arguments\n", os.Args[0])
 		flag.Usage()
 		exitf("")
 	}
 
-	var in io.Reader = os.Stdin
-	if *input != "" {
+	var baseline *gtr.Report
+	if *benchBaseline != "" {
+		f, err := os.Open(*benchBaseline)
+		if err != nil {
+			exitf("error opening benchmark baseline file: %v", err)
+		}
+		report, err := gojunitreport.ReadReport(f)
+		f.Close()
+		if err != nil {
+			exitf("error reading benchmark baseline: %v", err)
+		}
+		baseline = &report
+	}
+
+	var quarantined gojunitreport.Quarantine
+	if *quarantine != "" {
+		f, err := os.Open(*quarantine)
+		if err != nil {
+			exitf("error opening quarantine file: %v", err)
+		}
+		quarantined, err = gojunitreport.ReadQuarantine(f)
+		f.Close()
+		if err != nil {
+			exitf("error reading quarantine file: %v", err)
+		}
+	}
+
+	coverage := gojunitreport.CoverageThresholds{Package: *minCoverage, Total: *minTotalCoverage}
+	if *coverageThresholds != "" {
+		f, err := os.Open(*coverageThresholds)
+		if err != nil {
+			exitf("error opening coverage thresholds file: %v", err)
+		}
+		coverage.Overrides, err = gojunitreport.ReadCoverageThresholds(f)
+		f.Close()
+		if err != nil {
+			exitf("error reading coverage thresholds file: %v", err)
+		}
+	}
+	var profiles []string
+	if *coverProfiles != "" {
+		profiles = strings.Split(*coverProfiles, ",")
+	}
+	if *coberturaOutput != "" && len(profiles) == 0 {
+		exitf("you must specify coverage profiles with -coverprofile when using -cobertura")
+	}
+	if *coverageFailure != "exit" && *coverageFailure != "testcase" {
+		exitf("invalid value for -coverage-failure: %s\n", *coverageFailure)
+	}
+
+	redactor, err := newRedactor()
+	if err != nil {
+		exitf("error reading redaction rules: %v", err)
+	}
+
+	var in, stderr io.Reader = os.Stdin, nil
+	var cmd *exec.Cmd
+	if len(command) > 0 {
+		var err error
+		if cmd, in, stderr, err = startCommand(command); err != nil {
+			exitf("error running command: %v", err)
+		}
+	} else if *input != "" {
 		f, err := os.Open(*input)
 		if err != nil {
 			exitf("error opening input file: %v", err)
@@ -95,29 +267,206 @@ func main() {
 
 	if *iocopy {
 		in = io.TeeReader(in, os.Stdout)
+		if stderr != nil {
+			stderr = io.TeeReader(stderr, os.Stderr)
+		}
 	}
 
-	hostname, _ := os.Hostname() // ignore error
+	var hostname string
+	if !*reproducible {
+		hostname, _ = os.Hostname() // ignore error
+	}
 
 	config := gojunitreport.Config{
 		Parser:        *parser,
+		OutputFormat:  *format,
 		Hostname:      hostname,
 		PackageName:   *packageName,
 		SkipXMLHeader: *noXMLHeader,
 		SubtestMode:   subtestMode,
 		Properties:    properties,
+		TimestampFunc: timestampFunc,
 		PrintEvents:   *printEvents,
+		ModuleRoot:    *moduleRoot,
+		Kinds:         testKinds,
+		GroupByKind:   *groupByKind,
+		SubtestFormat: subtestFormat,
+		GroupAttempts: *attempts,
+		Quarantine:    quarantined,
+		Redactor:      redactor,
+		SpillOutput:   *spillOutput || *spillDir != "",
+		SpillDir:      *spillDir,
+		OutputLimits: gojunitreport.OutputLimits{
+			Test:    int(maxTestOutput),
+			Package: int(maxPackageOutput),
+			Total:   int(maxTotalOutput),
+			Policy:  policy,
+		},
+
+		ClassnameTemplate: *classnameTemplate,
+		NameTemplate:      *nameTemplate,
+		SuiteNameTemplate: *suiteNameTemplate,
+
+		CoverProfiles:      profiles,
+		CoverageThresholds: coverage,
+		CoverageTestcases:  *coverageFailure == "testcase",
+
+		BenchmarkBaseline: baseline,
+		BenchmarkThresholds: gojunitreport.BenchmarkThresholds{
+			NsPerOp:     *benchThresholdNs,
+			BytesPerOp:  *benchThresholdBytes,
+			AllocsPerOp: *benchThresholdAllocs,
+		},
 	}
-	report, err := config.Run(in, out)
+	report, err := config.RunStreams(in, stderr, out)
 	if err != nil {
 		exitf("error: %v\n", err)
 	}
 
-	if *setExitCode && !report.IsSuccessful() {
+	if *coberturaOutput != "" {
+		ts := time.Now()
+		if timestampFunc != nil {
+			ts = timestampFunc()
+		}
+		if err := writeCobertura(*coberturaOutput, *report.Coverage, ts); err != nil {
+			exitf("error writing Cobertura report: %v\n", err)
+		}
+	}
+
+	if *rerunFile != "" {
+		if err := writeRerunFile(*rerunFile, *report); err != nil {
+			exitf("error writing rerun file: %v\n", err)
+		}
+	}
+
+	// A test binary also exits with a non-zero status when only quarantined
+	// tests failed, so the exit status is ignored if any failures were
+	// quarantined. Any other failures still fail the report itself.
+	commandFailed := false
+	if cmd != nil {
+		if err := cmd.Wait(); err != nil {
+			if _, ok := err.(*exec.ExitError); !ok {
+				exitf("error running command: %v", err)
+			}
+			commandFailed = !gojunitreport.HasQuarantinedFailures(*report)
+		}
+	}
+
+	coverageFailed := false
+	if *coverageFailure == "exit" {
+		for _, shortfall := range coverage.Check(*report) {
+			fmt.Fprintln(os.Stderr, shortfall)
+			coverageFailed = true
+		}
+	}
+
+	if coverageFailed || (*setExitCode && (!report.IsSuccessful() || commandFailed)) {
 		os.Exit(1)
 	}
 }
 
+// newRedactor returns a Redactor for the redaction flags, or nil if redaction
+// is disabled.
+func newRedactor() (*gotest.Redactor, error) {
+	if !*redact && *redactRules == "" && *redactEnv == "" {
+		return nil, nil
+	}
+
+	r := gotest.NewRedactor()
+	if *redact {
+		r.AddBuiltinPatterns()
+	}
+	if *redactRules != "" {
+		f, err := os.Open(*redactRules)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		if err := r.ReadPatterns(f); err != nil {
+			return nil, err
+		}
+	}
+	if *redactEnv != "" {
+		for _, name := range strings.Split(*redactEnv, ",") {
+			r.AddValue(os.Getenv(strings.TrimSpace(name)))
+		}
+	}
+	return r, nil
+}
+
+// newTimestampFunc returns a function that returns the fixed timestamp set by
+// the reproducibility flags, or nil if the current time should be used.
+func newTimestampFunc() (func() time.Time, error) {
+	value := *timestamp
+	if value == "" && *reproducible {
+		value = os.Getenv("SOURCE_DATE_EPOCH")
+		if value == "" {
+			return func() time.Time { return time.Time{} }, nil
+		}
+	}
+	if value == "" {
+		return nil, nil
+	}
+
+	var ts time.Time
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		ts = time.Unix(seconds, 0).UTC()
+	} else if ts, err = time.Parse(time.RFC3339, value); err != nil {
+		return nil, err
+	}
+	return func() time.Time { return ts }, nil
+}
+
+// writeCobertura writes a Cobertura XML report for coverage to the file with
+// the given name.
+func writeCobertura(name string, coverage gtr.Coverage, timestamp time.Time) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := gojunitreport.WriteCobertura(f, coverage, *moduleRoot, timestamp); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeRerunFile writes the commands to rerun the failed tests in report to
+// the file with the given name, in the format set by the -rerun-format flag.
+func writeRerunFile(name string, report gtr.Report) error {
+	write := gojunitreport.WriteRerunCommands
+	if *rerunFormat == "json" {
+		write = gojunitreport.WriteRerunJSON
+	}
+
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := write(f, gojunitreport.RerunCommands(report)); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// startCommand starts the command in args and returns readers for its stdout
+// and stderr.
+func startCommand(args []string) (cmd *exec.Cmd, stdout, stderr io.Reader, err error) {
+	cmd = exec.Command(args[0], args[1:]...)
+	cmd.Stdin = os.Stdin
+	if stdout, err = cmd.StdoutPipe(); err != nil {
+		return nil, nil, nil, err
+	}
+	if stderr, err = cmd.StderrPipe(); err != nil {
+		return nil, nil, nil, err
+	}
+	if err = cmd.Start(); err != nil {
+		return nil, nil, nil, err
+	}
+	return cmd, stdout, stderr, nil
+}
+
 func exitf(msg string, args ...interface{}) {
 	if msg != "" {
 		fmt.Fprintf(os.Stderr, msg+"\n", args...)
@@ -147,3 +496,30 @@ func (f *keyValueFlag) Set(value string) error {
 	(*f)[k] = v
 	return nil
 }
+
+// byteSize is a flag.Value for a size in bytes, with an optional KB, MB or GB
+// suffix.
+type byteSize int
+
+func (s *byteSize) String() string {
+	return strconv.Itoa(int(*s))
+}
+
+func (s *byteSize) Set(value string) error {
+	multiplier := 1
+	for _, unit := range []struct {
+		suffix string
+		size   int
+	}{{"KB", 1 << 10}, {"MB", 1 << 20}, {"GB", 1 << 30}} {
+		if strings.HasSuffix(strings.ToUpper(value), unit.suffix) {
+			value, multiplier = value[:len(value)-len(unit.suffix)], unit.size
+			break
+		}
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || n < 0 {
+		return fmt.Errorf("invalid size: %s", value)
+	}
+	*s = byteSize(n * multiplier)
+	return nil
+}
@@ -0,0 +1,108 @@
+// Package coverprofile is a parser for the coverage profiles written by
+// `go test -coverprofile`.
+package coverprofile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jstemmer/go-junit-report/v2/gtr"
+)
+
+// blockRe matches a single block in a coverage profile, e.g.
+// "example.com/pkg/file.go:10.2,12.16 2 1".
+var blockRe = regexp.MustCompile(`^(.+):(\d+)\.(\d+),(\d+)\.(\d+) (\d+) (\d+)$`)
+
+// Parse parses a coverage profile in the set, count or atomic mode from r.
+// Profiles that contain multiple mode lines, e.g. when several profiles were
+// concatenated, are supported as long as all modes are equal. Blocks that
+// appear more than once are merged.
+func Parse(r io.Reader) (gtr.Coverage, error) {
+	var coverage gtr.Coverage
+	files := make(map[string][]gtr.CoverageBlock)
+	var names []string
+
+	s := bufio.NewScanner(r)
+	n := 0
+	for s.Scan() {
+		n++
+		line := strings.TrimSpace(s.Text())
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "mode: ") {
+			mode := strings.TrimPrefix(line, "mode: ")
+			if mode != "set" && mode != "count" && mode != "atomic" {
+				return gtr.Coverage{}, fmt.Errorf("line %d: unknown mode: %s", n, mode)
+			}
+			if coverage.Mode != "" && coverage.Mode != mode {
+				return gtr.Coverage{}, fmt.Errorf("line %d: mode %s does not match earlier mode %s", n, mode, coverage.Mode)
+			}
+			coverage.Mode = mode
+			continue
+		}
+		if coverage.Mode == "" {
+			return gtr.Coverage{}, fmt.Errorf("line %d: missing mode line", n)
+		}
+
+		name, block, err := parseBlock(line)
+		if err != nil {
+			return gtr.Coverage{}, fmt.Errorf("line %d: %w", n, err)
+		}
+		if _, ok := files[name]; !ok {
+			names = append(names, name)
+		}
+		files[name] = append(files[name], block)
+	}
+	if err := s.Err(); err != nil {
+		return gtr.Coverage{}, err
+	}
+
+	for _, name := range names {
+		coverage.Files = append(coverage.Files, gtr.CoverageFile{Name: name, Blocks: files[name]})
+	}
+
+	// Merging into an empty Coverage sorts the files and blocks, and merges
+	// duplicate blocks.
+	var result gtr.Coverage
+	if err := result.Merge(coverage); err != nil {
+		return gtr.Coverage{}, err
+	}
+	return result, nil
+}
+
+// parseBlock parses a single line containing a block and returns the name of
+// its file and the block.
+func parseBlock(line string) (string, gtr.CoverageBlock, error) {
+	matches := blockRe.FindStringSubmatch(line)
+	if matches == nil {
+		return "", gtr.CoverageBlock{}, fmt.Errorf("invalid block: %s", line)
+	}
+
+	var ints [5]int
+	for i := range ints {
+		v, err := strconv.Atoi(matches[i+2])
+		if err != nil {
+			return "", gtr.CoverageBlock{}, err
+		}
+		ints[i] = v
+	}
+	count, err := strconv.ParseInt(matches[7], 10, 64)
+	if err != nil {
+		return "", gtr.CoverageBlock{}, err
+	}
+
+	return matches[1], gtr.CoverageBlock{
+		StartLine:  ints[0],
+		StartCol:   ints[1],
+		EndLine:    ints[2],
+		EndCol:     ints[3],
+		Statements: ints[4],
+		Count:      count,
+	}, nil
+}
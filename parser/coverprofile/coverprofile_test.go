@@ -0,0 +1,65 @@
+package coverprofile
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jstemmer/go-junit-report/v2/gtr"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParse(t *testing.T) {
+	input := `mode: count
+example.com/pkg/b.go:3.14,5.2 1 0
+example.com/pkg/a.go:10.2,12.16 2 3
+example.com/pkg/a.go:3.14,5.2 1 1
+mode: count
+example.com/pkg/a.go:10.2,12.16 2 4
+`
+	want := gtr.Coverage{
+		Mode: "count",
+		Files: []gtr.CoverageFile{
+			{
+				Name: "example.com/pkg/a.go",
+				Blocks: []gtr.CoverageBlock{
+					{StartLine: 3, StartCol: 14, EndLine: 5, EndCol: 2, Statements: 1, Count: 1},
+					{StartLine: 10, StartCol: 2, EndLine: 12, EndCol: 16, Statements: 2, Count: 7},
+				},
+			},
+			{
+				Name: "example.com/pkg/b.go",
+				Blocks: []gtr.CoverageBlock{
+					{StartLine: 3, StartCol: 14, EndLine: 5, EndCol: 2, Statements: 1, Count: 0},
+				},
+			},
+		},
+	}
+
+	got, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Parse incorrect, diff (-want, +got):\n%s\n", diff)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{"example.com/pkg/a.go:1.1,2.2 1 1\n", "line 1: missing mode line"},
+		{"mode: unknown\n", "line 1: unknown mode: unknown"},
+		{"mode: set\nmode: count\n", "line 2: mode count does not match earlier mode set"},
+		{"mode: set\nexample.com/pkg/a.go:1.1 1 1\n", "line 2: invalid block: example.com/pkg/a.go:1.1 1 1"},
+	}
+
+	for _, test := range tests {
+		_, err := Parse(strings.NewReader(test.input))
+		if err == nil || err.Error() != test.err {
+			t.Errorf("Parse(%q) returned error %v, want %q", test.input, err, test.err)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package gotest
 
 import (
+	"sort"
+	"strconv"
 	"time"
 
 	"github.com/jstemmer/go-junit-report/v2/gtr"
@@ -18,6 +20,16 @@ type Benchmark struct {
 	MBPerSec    float64
 	BytesPerOp  int64
 	AllocsPerOp int64
+
+	// Metrics contains all the metrics that were reported for this
+	// benchmark, keyed by their unit. This includes the standard ns/op, MB/s,
+	// B/op and allocs/op metrics as well as any custom metrics reported by
+	// b.ReportMetric.
+	Metrics map[string]float64
+
+	// CPU is the GOMAXPROCS value the benchmark ran with, or 0 if it was not
+	// reported in the benchmark name.
+	CPU int
 }
 
 // ApproximateDuration returns the duration calculated by multiplying the
@@ -26,6 +38,28 @@ func (b Benchmark) ApproximateDuration() time.Duration {
 	return time.Duration(float64(b.Iterations)*b.NsPerOp) * time.Nanosecond
 }
 
+// Properties returns the metrics of this benchmark as a list of properties,
+// sorted by unit, followed by the "benchmark.cpu" property if CPU is known.
+func (b Benchmark) Properties() []gtr.Property {
+	units := make([]string, 0, len(b.Metrics))
+	for unit := range b.Metrics {
+		units = append(units, unit)
+	}
+	sort.Strings(units)
+
+	var props []gtr.Property
+	for _, unit := range units {
+		props = append(props, gtr.Property{
+			Name:  unit,
+			Value: strconv.FormatFloat(b.Metrics[unit], 'f', -1, 64),
+		})
+	}
+	if b.CPU > 0 {
+		props = append(props, gtr.Property{Name: "benchmark.cpu", Value: strconv.Itoa(b.CPU)})
+	}
+	return props
+}
+
 // GetBenchmarkData is a helper function that returns the benchmark contained
 // in the data field of the given gtr.Test t. If no (valid) benchmark is
 // present, ok will be set to false.
@@ -16,17 +16,34 @@ type Event struct {
 	Duration time.Duration `json:"duration,omitempty"`
 	Data     string        `json:"data,omitempty"`
 	Indent   int           `json:"indent,omitempty"`
+	Stderr   bool          `json:"stderr,omitempty"` // line was written to stderr
+
+	// Redactions is the number of secrets that were redacted from the line
+	// that caused this event.
+	Redactions int `json:"redactions,omitempty"`
 
 	// Code coverage
 	CovPct      float64  `json:"coverage_percentage,omitempty"`
 	CovPackages []string `json:"coverage_packages,omitempty"`
 
 	// Benchmarks
+	CPU         int     `json:"benchmark_cpu,omitempty"`
 	Iterations  int64   `json:"benchmark_iterations,omitempty"`
 	NsPerOp     float64 `json:"benchmark_ns_per_op,omitempty"`
 	MBPerSec    float64 `json:"benchmark_mb_per_sec,omitempty"`
 	BytesPerOp  int64   `json:"benchmark_bytes_per_op,omitempty"`
 	AllocsPerOp int64   `json:"benchmark_allocs_per_op,omitempty"`
+
+	// Benchmark metrics keyed by unit, including custom metrics
+	Metrics map[string]float64 `json:"benchmark_metrics,omitempty"`
+
+	// Fuzzing
+	FuzzExecs            int64 `json:"fuzz_execs,omitempty"`
+	FuzzNewInteresting   int64 `json:"fuzz_new_interesting,omitempty"`
+	FuzzTotalInteresting int64 `json:"fuzz_total_interesting,omitempty"`
+
+	// Data races
+	Race *Race `json:"race,omitempty"`
 }
 
 func (e *Event) applyMetadata(m *reader.Metadata) {
@@ -34,4 +51,5 @@ func (e *Event) applyMetadata(m *reader.Metadata) {
 		return
 	}
 	e.Package = m.Package
+	e.Stderr = m.Stderr
 }
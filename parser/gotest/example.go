@@ -0,0 +1,114 @@
+package gotest
+
+import (
+	"github.com/jstemmer/go-junit-report/v2/gtr"
+)
+
+const (
+	exampleKey = "gotest.example"
+
+	// maxDiffSize is the maximum product of the number of wanted and actual
+	// lines for which Diff computes a diff.
+	maxDiffSize = 1 << 20
+)
+
+// Example contains the output of a failed example test and is intended to be
+// used as extra data in a gtr.Test.
+type Example struct {
+	Got  []string
+	Want []string
+}
+
+// Diff returns a line based diff between the wanted and the actual output of
+// the example. Lines that are only in Want are prefixed with '-', lines that
+// are only in Got are prefixed with '+'. If the output is too large to diff,
+// the got and want blocks are returned unchanged instead.
+func (e Example) Diff() []string {
+	n, m := len(e.Want), len(e.Got)
+	if n*m > maxDiffSize {
+		lines := append([]string{"got:"}, e.Got...)
+		lines = append(lines, "want:")
+		return append(lines, e.Want...)
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of Want[i:]
+	// and Got[j:].
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if e.Want[i] == e.Got[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	diff := []string{"--- want", "+++ got"}
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && e.Want[i] == e.Got[j]:
+			diff = append(diff, " "+e.Want[i])
+			i++
+			j++
+		case j == m || (i < n && lcs[i+1][j] >= lcs[i][j+1]):
+			diff = append(diff, "-"+e.Want[i])
+			i++
+		default:
+			diff = append(diff, "+"+e.Got[j])
+			j++
+		}
+	}
+	return diff
+}
+
+// parseExampleOutput finds the got and want blocks printed by a failed
+// example in output. It returns the example and the index of the line where
+// the got block starts. The got block ends at the first "want:" line after
+// it. If output does not contain a got and want block, ok will be set to
+// false.
+func parseExampleOutput(output []string) (e Example, start int, ok bool) {
+	start, want := -1, -1
+	for i, line := range output {
+		if line == "got:" && start < 0 {
+			start = i
+		} else if line == "want:" && start >= 0 {
+			want = i
+			break
+		}
+	}
+	if start < 0 || want < 0 {
+		return Example{}, 0, false
+	}
+	return Example{
+		Got:  output[start+1 : want],
+		Want: output[want+1:],
+	}, start, true
+}
+
+// GetExampleData is a helper function that returns the example output
+// contained in the data field of the given gtr.Test t. If no (valid) example
+// output is present, ok will be set to false.
+func GetExampleData(t gtr.Test) (e Example, ok bool) {
+	if t.Data != nil {
+		if data, exists := t.Data[exampleKey]; exists {
+			e, ok := data.(Example)
+			return e, ok
+		}
+	}
+	return Example{}, false
+}
+
+// SetExampleData is a helper function that writes the example output e to
+// the data field of the given gtr.Test t.
+func SetExampleData(t *gtr.Test, e Example) {
+	if t.Data != nil {
+		t.Data[exampleKey] = e
+	}
+}
@@ -0,0 +1,77 @@
+package gotest
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExampleDiff(t *testing.T) {
+	tests := []struct {
+		name    string
+		example Example
+		want    []string
+	}{
+		{
+			"equal",
+			Example{Got: []string{"a", "b"}, Want: []string{"a", "b"}},
+			[]string{"--- want", "+++ got", " a", " b"},
+		},
+		{
+			"changed",
+			Example{Got: []string{"a", "x", "c"}, Want: []string{"a", "b", "c"}},
+			[]string{"--- want", "+++ got", " a", "-b", "+x", " c"},
+		},
+		{
+			"added and removed",
+			Example{Got: []string{"a", "b", "d"}, Want: []string{"b", "c", "d"}},
+			[]string{"--- want", "+++ got", "+a", " b", "-c", " d"},
+		},
+		{
+			"empty got",
+			Example{Want: []string{"a"}},
+			[]string{"--- want", "+++ got", "-a"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.example.Diff()
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Diff() incorrect, diff (-want, +got):\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestExampleDiffTooLarge(t *testing.T) {
+	lines := make([]string, 2000)
+	for i := range lines {
+		lines[i] = strconv.Itoa(i)
+	}
+	e := Example{Got: lines[1:], Want: lines[:len(lines)-1]}
+
+	got := e.Diff()
+	if len(got) != 2*len(lines) {
+		t.Fatalf("Diff() returned %d lines, want %d", len(got), 2*len(lines))
+	}
+	if got[0] != "got:" || got[len(lines)] != "want:" {
+		t.Errorf("Diff() did not return the got and want blocks, got %q and %q", got[0], got[len(lines)])
+	}
+}
+
+func TestParseExampleOutput(t *testing.T) {
+	output := []string{"=== RUN   ExampleA", "--- FAIL: ExampleA (0.00s)", "got:", "a", "want:", "b", "want:", "c"}
+	e, start, ok := parseExampleOutput(output)
+	if !ok {
+		t.Fatalf("parseExampleOutput did not find the example output")
+	}
+	if start != 2 {
+		t.Errorf("parseExampleOutput start = %d, want 2", start)
+	}
+	want := Example{Got: []string{"a"}, Want: []string{"b", "want:", "c"}}
+	if diff := cmp.Diff(want, e); diff != "" {
+		t.Errorf("parseExampleOutput incorrect, diff (-want, +got):\n%s\n", diff)
+	}
+}
@@ -0,0 +1,60 @@
+package gotest
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/jstemmer/go-junit-report/v2/gtr"
+)
+
+const (
+	fuzzKey = "gotest.fuzz"
+)
+
+// Fuzz contains the results of running a fuzz test with the fuzzing engine
+// enabled and is intended to be used as extra data in a gtr.Test.
+type Fuzz struct {
+	Elapsed          time.Duration
+	Execs            int64
+	NewInteresting   int64
+	TotalInteresting int64
+
+	// FailingInput is the path of the corpus file containing the input that
+	// caused the fuzz test to fail.
+	FailingInput string
+}
+
+// Properties returns the fuzzing statistics as a list of properties.
+func (f Fuzz) Properties() []gtr.Property {
+	props := []gtr.Property{
+		{Name: "fuzz.elapsed", Value: f.Elapsed.String()},
+		{Name: "fuzz.execs", Value: strconv.FormatInt(f.Execs, 10)},
+		{Name: "fuzz.new_interesting", Value: strconv.FormatInt(f.NewInteresting, 10)},
+		{Name: "fuzz.total_interesting", Value: strconv.FormatInt(f.TotalInteresting, 10)},
+	}
+	if f.FailingInput != "" {
+		props = append(props, gtr.Property{Name: "fuzz.failing_input", Value: f.FailingInput})
+	}
+	return props
+}
+
+// GetFuzzData is a helper function that returns the fuzzing results contained
+// in the data field of the given gtr.Test t. If no (valid) fuzzing results are
+// present, ok will be set to false.
+func GetFuzzData(t gtr.Test) (f Fuzz, ok bool) {
+	if t.Data != nil {
+		if data, exists := t.Data[fuzzKey]; exists {
+			f, ok := data.(Fuzz)
+			return f, ok
+		}
+	}
+	return Fuzz{}, false
+}
+
+// SetFuzzData is a helper function that writes the fuzzing results f to the
+// data field of the given gtr.Test t.
+func SetFuzzData(t *gtr.Test, f Fuzz) {
+	if t.Data != nil {
+		t.Data[fuzzKey] = f
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/jstemmer/go-junit-report/v2/gtr"
+	"github.com/jstemmer/go-junit-report/v2/parser/gotest/internal/collector"
 	"github.com/jstemmer/go-junit-report/v2/parser/gotest/internal/reader"
 )
 
@@ -18,14 +19,27 @@ const (
 	// maxLineSize is the maximum amount of bytes we'll read for a single line.
 	// Lines longer than maxLineSize will be truncated.
 	maxLineSize = 4 * 1024 * 1024
+
+	// raceSeparator is printed by the race detector before and after each
+	// data race report.
+	raceSeparator = "=================="
 )
 
 var (
-	// regexBenchInfo captures 3-5 groups: benchmark name, number of times ran, ns/op (with or without decimal), MB/sec (optional), B/op (optional), and allocs/op (optional).
-	regexBenchmark    = regexp.MustCompile(`^(Benchmark[^ -]+)$`)
-	regexBenchSummary = regexp.MustCompile(`^(Benchmark[^ -]+)(?:-\d+\s+|\s+)(\d+)\s+(\d+|\d+\.\d+)\sns\/op(?:\s+(\d+|\d+\.\d+)\sMB\/s)?(?:\s+(\d+)\sB\/op)?(?:\s+(\d+)\sallocs/op)?`)
+	regexBenchmark = regexp.MustCompile(`^(Benchmark[^ -]+)$`)
+	// regexBenchSummary captures 4 groups: benchmark name, GOMAXPROCS
+	// (optional), number of times ran and a list of one or more `value unit`
+	// metric pairs.
+	regexBenchSummary = regexp.MustCompile(`^(Benchmark[^ -]+)(?:-(\d+)\s+|\s+)(\d+)((?:\s+-?\d+(?:\.\d+)?\s+[^\s]+)+)`)
+	regexBenchHeader  = regexp.MustCompile(`^(goos|goarch|cpu): (.+)$`)
 	regexCoverage     = regexp.MustCompile(`^coverage:\s+(\d+|\d+\.\d+)%\s+of\s+statements(?:\sin\s(.+))?$`)
-	regexEndBenchmark = regexp.MustCompile(`^--- (BENCH|FAIL|SKIP): (Benchmark[^ -]+)(?:-\d+)?$`)
+	regexEndBenchmark = regexp.MustCompile(`^--- (BENCH|FAIL|SKIP): (Benchmark[^ -]+)(?:-(\d+))?$`)
+	regexFuzzProgress = regexp.MustCompile(`^fuzz: elapsed: (\S+), execs: (\d+) \(\d+/sec\), new interesting: (\d+) \(total: (\d+)\)$`)
+	regexFuzzInput    = regexp.MustCompile(`^\s*Failing input written to (\S*?([^/\s]+)/[^/\s]+)$`)
+	regexRunningTest  = regexp.MustCompile(`^\s+(\S+) \((\S+)\)$`)
+	regexTimeout      = regexp.MustCompile(`^panic: (test timed out after (\S+))$`)
+	regexPanic        = regexp.MustCompile(`^panic: (.+?)(?: \[recovered(?:, repanicked)?\])?$`)
+	regexRaceDetected = regexp.MustCompile(`^\s*testing\.go:\d+: race detected during execution of test$`)
 	regexEndTest      = regexp.MustCompile(`((?:    )*)--- (PASS|FAIL|SKIP): ([^ ]+) \((\d+\.\d+)(?: seconds|s)\)`)
 	regexStatus       = regexp.MustCompile(`^(PASS|FAIL|SKIP)$`)
 	regexSummary      = regexp.MustCompile(`` +
@@ -109,14 +123,68 @@ func SetSubtestMode(mode SubtestMode) Option {
 	}
 }
 
+// RedactOutput is an Option that replaces secrets in all lines of test
+// output using the given Redactor, before they are parsed. The number of
+// redactions in each package is added to the report as the "redaction.count"
+// package property.
+func RedactOutput(r *Redactor) Option {
+	return func(p *Parser) {
+		p.redactor = r
+	}
+}
+
+// OutputDir is an Option that stores the captured test output in a
+// temporary file in dir while parsing, instead of keeping it in memory. If dir
+// is empty, the default directory for temporary files is used. The file is
+// removed once parsing has finished. The returned report still contains all
+// output, unless it is reduced by a PackageFunc.
+func OutputDir(dir string) Option {
+	return func(p *Parser) {
+		p.useOutputFile = true
+		p.outputDir = dir
+	}
+}
+
+// DiscardEvents is an Option that prevents the parser from retaining the
+// events it created, which reduces the memory used for parsing large inputs.
+// When this option is used, Events returns nil.
+func DiscardEvents() Option {
+	return func(p *Parser) {
+		p.discardEvents = true
+	}
+}
+
+// PackageFunc is an Option that calls f for each package as soon as it has
+// been created, before the rest of the input is parsed. f may modify the
+// package, for example to truncate its output so that the output of packages
+// that have already been parsed does not need to be kept in memory.
+func PackageFunc(f func(pkg *gtr.Package)) Option {
+	return func(p *Parser) {
+		p.packageFunc = f
+	}
+}
+
 // Parser is a Go test output Parser.
 type Parser struct {
 	packageName string
 	subtestMode SubtestMode
+	redactor    *Redactor
+
+	useOutputFile bool
+	outputDir     string
+	discardEvents bool
+	packageFunc   func(pkg *gtr.Package)
 
 	timestampFunc func() time.Time
 
 	events []Event
+
+	// runningTests is true while parsing the list of tests that were still
+	// running when a test binary timed out.
+	runningTests bool
+
+	// race is set while parsing a data race report.
+	race *raceParser
 }
 
 // NewParser returns a new Go test output parser.
@@ -134,15 +202,39 @@ func (p *Parser) Parse(r io.Reader) (gtr.Report, error) {
 	return p.parse(reader.NewLimitedLineReader(r, maxLineSize))
 }
 
+// ParseStreams parses Go test output from the stdout and stderr of a process,
+// which are read concurrently, and returns gtr.Report. Output that was written
+// to stderr is reported separately from other output.
+func (p *Parser) ParseStreams(stdout, stderr io.Reader) (gtr.Report, error) {
+	r := reader.NewStreamLineReader(
+		reader.NewLimitedLineReader(stdout, maxLineSize),
+		reader.NewLimitedLineReader(stderr, maxLineSize),
+	)
+	defer r.Close()
+	return p.parse(r)
+}
+
 func (p *Parser) parse(r reader.LineReader) (gtr.Report, error) {
 	p.events = nil
+	p.runningTests = false
+	p.race = nil
 
 	rb := newReportBuilder()
 	rb.packageName = p.packageName
 	rb.subtestMode = p.subtestMode
+	rb.packageFunc = p.packageFunc
 	if p.timestampFunc != nil {
 		rb.timestampFunc = p.timestampFunc
 	}
+	if p.useOutputFile {
+		store, err := collector.NewFileStore(p.outputDir)
+		if err != nil {
+			return gtr.Report{}, err
+		}
+		defer store.Close()
+		rb.store = store
+		rb.output = collector.NewFileBacked(store)
+	}
 
 	for {
 		line, metadata, err := r.ReadLine()
@@ -168,23 +260,78 @@ func (p *Parser) parse(r reader.LineReader) (gtr.Report, error) {
 			evs = p.parseLine(line)
 		}
 
+		if redactions := p.redact(evs); redactions > 0 {
+			evs[0].Redactions = redactions
+		}
 		for _, ev := range evs {
 			ev.applyMetadata(metadata)
 			rb.ProcessEvent(ev)
-			p.events = append(p.events, ev)
+			if !p.discardEvents {
+				p.events = append(p.events, ev)
+			}
 		}
 	}
-	return rb.Build(), nil
+
+	report := rb.Build()
+	if rb.store != nil && rb.store.Err() != nil {
+		return gtr.Report{}, fmt.Errorf("error storing output: %w", rb.store.Err())
+	}
+	return report, nil
 }
 
-// Events returns the events created by the parser.
+// redact replaces secrets in the output and in the panic and timeout messages
+// of the given events, and returns the number of secrets in the output that
+// were replaced. Since lines are redacted after parsing, secrets cannot affect
+// how test results and package summaries are parsed.
+func (p *Parser) redact(events []Event) int {
+	if p.redactor == nil {
+		return 0
+	}
+	count := 0
+	for i := range events {
+		switch events[i].Type {
+		case "output":
+			var n int
+			events[i].Data, n = p.redactor.Redact(events[i].Data)
+			count += n
+		case "panic", "timeout":
+			events[i].Data, _ = p.redactor.Redact(events[i].Data)
+		}
+	}
+	return count
+}
+
+// Events returns the events created by the parser, unless the DiscardEvents
+// option was used.
 func (p *Parser) Events() []Event {
+	if p.discardEvents {
+		return nil
+	}
 	events := make([]Event, len(p.events))
 	copy(events, p.events)
 	return events
 }
 
 func (p *Parser) parseLine(line string) (events []Event) {
+	if p.runningTests {
+		if matches := regexRunningTest.FindStringSubmatch(line); len(matches) == 3 {
+			return p.timeoutTest(line, matches[1], matches[2])
+		}
+		p.runningTests = false
+	}
+
+	if p.race != nil {
+		if line == raceSeparator {
+			return p.dataRace(line)
+		} else if endsRace(line) {
+			// The race report was not terminated, e.g. because the output was
+			// truncated. End the race and parse this line as usual.
+			return append(p.endRace(), p.parseLine(line)...)
+		}
+		p.race.parseLine(line)
+		return p.output(line)
+	}
+
 	if strings.HasPrefix(line, "=== RUN ") {
 		return p.runTest(strings.TrimSpace(line[8:]))
 	} else if strings.HasPrefix(line, "=== PAUSE ") {
@@ -196,6 +343,20 @@ func (p *Parser) parseLine(line string) (events []Event) {
 		return p.contTest(strings.TrimSpace(line[9:]))
 	} else if matches := regexEndTest.FindStringSubmatch(line); len(matches) == 5 {
 		return p.endTest(line, matches[1], matches[2], matches[3], matches[4])
+	} else if matches := regexTimeout.FindStringSubmatch(line); len(matches) == 3 {
+		return p.timeout(line, matches[1], matches[2])
+	} else if matches := regexPanic.FindStringSubmatch(line); len(matches) == 2 {
+		return p.panic(line, matches[1])
+	} else if line == "WARNING: DATA RACE" {
+		p.race = &raceParser{}
+		return p.output(line)
+	} else if regexRaceDetected.MatchString(line) {
+		return p.raceDetected(line)
+	} else if line == "got:" {
+		return p.exampleOutput(line)
+	} else if line == "running tests:" {
+		p.runningTests = true
+		return p.output(line)
 	} else if matches := regexStatus.FindStringSubmatch(line); len(matches) == 2 {
 		return p.status(matches[1])
 	} else if matches := regexSummary.FindStringSubmatch(line); len(matches) == 8 {
@@ -204,10 +365,16 @@ func (p *Parser) parseLine(line string) (events []Event) {
 		return p.coverage(matches[1], matches[2])
 	} else if matches := regexBenchmark.FindStringSubmatch(line); len(matches) == 2 {
 		return p.runBench(matches[1])
-	} else if matches := regexBenchSummary.FindStringSubmatch(line); len(matches) == 7 {
-		return p.benchSummary(matches[1], matches[2], matches[3], matches[4], matches[5], matches[6])
-	} else if matches := regexEndBenchmark.FindStringSubmatch(line); len(matches) == 3 {
-		return p.endBench(matches[1], matches[2])
+	} else if matches := regexBenchSummary.FindStringSubmatch(line); len(matches) == 5 {
+		return p.benchSummary(matches[1], matches[2], matches[3], matches[4])
+	} else if matches := regexEndBenchmark.FindStringSubmatch(line); len(matches) == 4 {
+		return p.endBench(matches[1], matches[2], matches[3])
+	} else if matches := regexBenchHeader.FindStringSubmatch(line); len(matches) == 3 {
+		return p.benchHeader(matches[1], matches[2])
+	} else if matches := regexFuzzProgress.FindStringSubmatch(line); len(matches) == 5 {
+		return p.fuzzProgress(matches[1], matches[2], matches[3], matches[4])
+	} else if matches := regexFuzzInput.FindStringSubmatch(line); len(matches) == 3 {
+		return p.fuzzFailingInput(line, matches[2], matches[1])
 	} else if strings.HasPrefix(line, "# ") {
 		fields := strings.Fields(strings.TrimPrefix(line, "# "))
 		if len(fields) == 1 || len(fields) == 2 {
@@ -276,24 +443,133 @@ func (p *Parser) runBench(name string) []Event {
 	}}
 }
 
-func (p *Parser) benchSummary(name, iterations, nsPerOp, mbPerSec, bytesPerOp, allocsPerOp string) []Event {
-	return []Event{{
-		Type:        "benchmark",
-		Name:        name,
-		Iterations:  parseInt(iterations),
-		NsPerOp:     parseFloat(nsPerOp),
-		MBPerSec:    parseFloat(mbPerSec),
-		BytesPerOp:  parseInt(bytesPerOp),
-		AllocsPerOp: parseInt(allocsPerOp),
-	}}
+func (p *Parser) benchSummary(name, cpu, iterations, metrics string) []Event {
+	event := Event{
+		Type:       "benchmark",
+		Name:       name,
+		CPU:        int(parseInt(cpu)),
+		Iterations: parseInt(iterations),
+		Metrics:    make(map[string]float64),
+	}
+
+	fields := strings.Fields(metrics)
+	for i := 0; i+1 < len(fields); i += 2 {
+		value, unit := parseFloat(fields[i]), fields[i+1]
+		switch unit {
+		case "ns/op":
+			event.NsPerOp = value
+		case "MB/s":
+			event.MBPerSec = value
+		case "B/op":
+			event.BytesPerOp = int64(value)
+		case "allocs/op":
+			event.AllocsPerOp = int64(value)
+		}
+		event.Metrics[unit] = value
+	}
+	return []Event{event}
 }
 
-func (p *Parser) endBench(result, name string) []Event {
+func (p *Parser) endBench(result, name, cpu string) []Event {
 	return []Event{{
 		Type:   "end_benchmark",
 		Name:   name,
 		Result: result,
+		CPU:    int(parseInt(cpu)),
+	}}
+}
+
+func (p *Parser) benchHeader(key, value string) []Event {
+	return []Event{{
+		Type: "benchmark_header",
+		Name: key,
+		Data: value,
+	}}
+}
+
+func (p *Parser) fuzzProgress(elapsed, execs, newInteresting, totalInteresting string) []Event {
+	// ignore error
+	d, _ := time.ParseDuration(elapsed)
+	return []Event{{
+		Type:                 "fuzz_progress",
+		Duration:             d,
+		FuzzExecs:            parseInt(execs),
+		FuzzNewInteresting:   parseInt(newInteresting),
+		FuzzTotalInteresting: parseInt(totalInteresting),
+	}}
+}
+
+func (p *Parser) fuzzFailingInput(line, name, path string) []Event {
+	events := []Event{{
+		Type: "fuzz_failing_input",
+		Name: name,
+		Data: path,
+	}}
+	return append(events, p.output(line)...)
+}
+
+func (p *Parser) timeout(line, message, duration string) []Event {
+	// ignore error
+	d, _ := time.ParseDuration(duration)
+	events := []Event{{
+		Type:     "timeout",
+		Data:     message,
+		Duration: d,
+	}}
+	return append(events, p.output(line)...)
+}
+
+func (p *Parser) panic(line, message string) []Event {
+	events := []Event{{
+		Type: "panic",
+		Data: message,
+	}}
+	return append(events, p.output(line)...)
+}
+
+func (p *Parser) dataRace(line string) []Event {
+	return append(p.endRace(), p.output(line)...)
+}
+
+func (p *Parser) endRace() []Event {
+	race := p.race.race
+	p.race = nil
+	return []Event{{
+		Type: "race",
+		Race: &race,
+	}}
+}
+
+// endsRace returns true if line cannot be part of a data race report, which
+// means that the report was not properly terminated.
+func endsRace(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, "--- ") ||
+		strings.HasPrefix(line, "=== ") ||
+		regexStatus.MatchString(line) ||
+		regexSummary.MatchString(line) ||
+		regexPanic.MatchString(line)
+}
+
+func (p *Parser) raceDetected(line string) []Event {
+	events := []Event{{Type: "race_detected"}}
+	return append(events, p.output(line)...)
+}
+
+func (p *Parser) exampleOutput(line string) []Event {
+	events := []Event{{Type: "example_output"}}
+	return append(events, p.output(line)...)
+}
+
+func (p *Parser) timeoutTest(line, name, elapsed string) []Event {
+	// ignore error
+	d, _ := time.ParseDuration(elapsed)
+	events := []Event{{
+		Type:     "timeout_test",
+		Name:     name,
+		Duration: d,
 	}}
+	return append(events, p.output(line)...)
 }
 
 func (p *Parser) buildOutput(packageName string) []Event {
@@ -1,11 +1,17 @@
 package gotest
 
 import (
+	"bytes"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/jstemmer/go-junit-report/v2/gtr"
+
 	"github.com/google/go-cmp/cmp"
 )
 
@@ -153,23 +159,31 @@ var parseLineTests = []parseLineTest{
 	},
 	{
 		"BenchmarkOne-8                     2000000	       604 ns/op",
-		[]Event{{Type: "benchmark", Name: "BenchmarkOne", Iterations: 2_000_000, NsPerOp: 604}},
+		[]Event{{Type: "benchmark", Name: "BenchmarkOne", CPU: 8, Iterations: 2_000_000, NsPerOp: 604, Metrics: map[string]float64{"ns/op": 604}}},
 	},
 	{
 		"BenchmarkTwo-16 30000	52568 ns/op	24879 B/op	494 allocs/op",
-		[]Event{{Type: "benchmark", Name: "BenchmarkTwo", Iterations: 30_000, NsPerOp: 52_568, BytesPerOp: 24_879, AllocsPerOp: 494}},
+		[]Event{{Type: "benchmark", Name: "BenchmarkTwo", CPU: 16, Iterations: 30_000, NsPerOp: 52_568, BytesPerOp: 24_879, AllocsPerOp: 494, Metrics: map[string]float64{"ns/op": 52_568, "B/op": 24_879, "allocs/op": 494}}},
 	},
 	{
 		"BenchmarkThree      2000000000	         0.26 ns/op",
-		[]Event{{Type: "benchmark", Name: "BenchmarkThree", Iterations: 2_000_000_000, NsPerOp: 0.26}},
+		[]Event{{Type: "benchmark", Name: "BenchmarkThree", Iterations: 2_000_000_000, NsPerOp: 0.26, Metrics: map[string]float64{"ns/op": 0.26}}},
 	},
 	{
 		"BenchmarkFour-8         	   10000	    104427 ns/op	  95.76 MB/s	   40629 B/op	       5 allocs/op",
-		[]Event{{Type: "benchmark", Name: "BenchmarkFour", Iterations: 10_000, NsPerOp: 104_427, MBPerSec: 95.76, BytesPerOp: 40_629, AllocsPerOp: 5}},
+		[]Event{{Type: "benchmark", Name: "BenchmarkFour", CPU: 8, Iterations: 10_000, NsPerOp: 104_427, MBPerSec: 95.76, BytesPerOp: 40_629, AllocsPerOp: 5, Metrics: map[string]float64{"ns/op": 104_427, "MB/s": 95.76, "B/op": 40_629, "allocs/op": 5}}},
+	},
+	{
+		"BenchmarkFive-8   	     100	     13571 ns/op	       251.0 p99-ns	         4.000 items/op",
+		[]Event{{Type: "benchmark", Name: "BenchmarkFive", CPU: 8, Iterations: 100, NsPerOp: 13_571, Metrics: map[string]float64{"ns/op": 13_571, "p99-ns": 251, "items/op": 4}}},
+	},
+	{
+		"BenchmarkSix-8   	     100	         1.500 custom/op",
+		[]Event{{Type: "benchmark", Name: "BenchmarkSix", CPU: 8, Iterations: 100, Metrics: map[string]float64{"custom/op": 1.5}}},
 	},
 	{
 		"--- BENCH: BenchmarkOK-8",
-		[]Event{{Type: "end_benchmark", Name: "BenchmarkOK", Result: "BENCH"}},
+		[]Event{{Type: "end_benchmark", Name: "BenchmarkOK", Result: "BENCH", CPU: 8}},
 	},
 	{
 		"--- FAIL: BenchmarkError",
@@ -179,6 +193,84 @@ var parseLineTests = []parseLineTest{
 		"--- SKIP: BenchmarkSkip",
 		[]Event{{Type: "end_benchmark", Name: "BenchmarkSkip", Result: "SKIP"}},
 	},
+	{
+		"goos: linux",
+		[]Event{{Type: "benchmark_header", Name: "goos", Data: "linux"}},
+	},
+	{
+		"goarch: amd64",
+		[]Event{{Type: "benchmark_header", Name: "goarch", Data: "amd64"}},
+	},
+	{
+		"pkg: package/name",
+		[]Event{{Type: "output", Data: "pkg: package/name"}},
+	},
+	{
+		"cpu: Intel(R) Core(TM) i7-8550U CPU @ 1.80GHz",
+		[]Event{{Type: "benchmark_header", Name: "cpu", Data: "Intel(R) Core(TM) i7-8550U CPU @ 1.80GHz"}},
+	},
+	{
+		"fuzz: elapsed: 3s, execs: 325017 (108336/sec), new interesting: 11 (total: 202)",
+		[]Event{{Type: "fuzz_progress", Duration: 3 * time.Second, FuzzExecs: 325_017, FuzzNewInteresting: 11, FuzzTotalInteresting: 202}},
+	},
+	{
+		"    Failing input written to testdata/fuzz/FuzzReverse/af69258a12129d6c",
+		[]Event{
+			{Type: "fuzz_failing_input", Name: "FuzzReverse", Data: "testdata/fuzz/FuzzReverse/af69258a12129d6c"},
+			{Type: "output", Data: "    Failing input written to testdata/fuzz/FuzzReverse/af69258a12129d6c"},
+		},
+	},
+	{
+		"fuzz: elapsed: 0s, gathering baseline coverage: 0/3 completed",
+		[]Event{{Type: "output", Data: "fuzz: elapsed: 0s, gathering baseline coverage: 0/3 completed"}},
+	},
+	{
+		"panic: test timed out after 10m0s",
+		[]Event{
+			{Type: "timeout", Data: "test timed out after 10m0s", Duration: 10 * time.Minute},
+			{Type: "output", Data: "panic: test timed out after 10m0s"},
+		},
+	},
+	{
+		"panic: runtime error: index out of range [3] with length 3",
+		[]Event{
+			{Type: "panic", Data: "runtime error: index out of range [3] with length 3"},
+			{Type: "output", Data: "panic: runtime error: index out of range [3] with length 3"},
+		},
+	},
+	{
+		"panic: oops [recovered]",
+		[]Event{
+			{Type: "panic", Data: "oops"},
+			{Type: "output", Data: "panic: oops [recovered]"},
+		},
+	},
+	{
+		"\tpanic: oops",
+		[]Event{{Type: "output", Data: "\tpanic: oops"}},
+	},
+	{
+		"WARNING: DATA RACE",
+		[]Event{{Type: "output", Data: "WARNING: DATA RACE"}},
+	},
+	{
+		"    testing.go:1312: race detected during execution of test",
+		[]Event{
+			{Type: "race_detected"},
+			{Type: "output", Data: "    testing.go:1312: race detected during execution of test"},
+		},
+	},
+	{
+		"got:",
+		[]Event{
+			{Type: "example_output"},
+			{Type: "output", Data: "got:"},
+		},
+	},
+	{
+		"running tests:",
+		[]Event{{Type: "output", Data: "running tests:"}},
+	},
 	{
 		"# package/name/failing1",
 		[]Event{{Type: "build_output", Name: "package/name/failing1"}},
@@ -225,3 +317,165 @@ func TestParseLine(t *testing.T) {
 		})
 	}
 }
+
+func TestParseDataRace(t *testing.T) {
+	input := `==================
+WARNING: DATA RACE
+Write at 0x00c000138168 by goroutine 8:
+  package/race.TestRace.func1()
+      /src/package/race/race_test.go:9 +0x39
+
+Previous read at 0x00c000138168 by goroutine 7:
+  package/race.TestRace()
+      /src/package/race/race_test.go:12 +0x105
+
+Goroutine 8 (running) created at:
+  package/race.TestRace()
+      /src/package/race/race_test.go:8 +0xfb
+  testing.tRunner()
+      /go/src/testing/testing.go:1439 +0x213
+==================
+`
+	want := Race{
+		Accesses: []RaceAccess{
+			{
+				Op:        "Write",
+				Addr:      "0x00c000138168",
+				Goroutine: 8,
+				Stack:     []StackFrame{{Function: "package/race.TestRace.func1()", File: "/src/package/race/race_test.go", Line: 9}},
+			},
+			{
+				Op:        "Previous read",
+				Addr:      "0x00c000138168",
+				Goroutine: 7,
+				Stack:     []StackFrame{{Function: "package/race.TestRace()", File: "/src/package/race/race_test.go", Line: 12}},
+			},
+		},
+		Goroutines: []RaceGoroutine{
+			{
+				ID:    8,
+				State: "running",
+				Stack: []StackFrame{
+					{Function: "package/race.TestRace()", File: "/src/package/race/race_test.go", Line: 8},
+					{Function: "testing.tRunner()", File: "/go/src/testing/testing.go", Line: 1439},
+				},
+			},
+		},
+	}
+
+	parser := NewParser()
+	if _, err := parser.Parse(strings.NewReader(input)); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	var races []Race
+	for _, ev := range parser.Events() {
+		if ev.Type == "race" {
+			races = append(races, *ev.Race)
+		}
+	}
+	if diff := cmp.Diff([]Race{want}, races); diff != "" {
+		t.Errorf("Parse returned unexpected races, diff (-want, +got):\n%v", diff)
+	}
+
+	wantMsg := "Data race: write by goroutine 8 at race_test.go:9, previous read by goroutine 7 at race_test.go:12"
+	if msg := want.Message(); msg != wantMsg {
+		t.Errorf("Race.Message() = %q, want %q", msg, wantMsg)
+	}
+}
+
+func TestParseStreams(t *testing.T) {
+	stdout := "=== RUN   TestOne\n--- PASS: TestOne (0.00s)\nPASS\nok  \tpackage/name\t0.001s\n"
+	stderr := "log message\n"
+
+	report, err := NewParser().ParseStreams(strings.NewReader(stdout), strings.NewReader(stderr))
+	if err != nil {
+		t.Fatalf("ParseStreams failed: %v", err)
+	}
+
+	// The order in which lines from both streams are read is not known, so
+	// only check that stderr was not mixed with the other output.
+	var gotOutput, gotStderr []string
+	for _, pkg := range report.Packages {
+		gotOutput = append(gotOutput, pkg.Output...)
+		gotStderr = append(gotStderr, pkg.Stderr...)
+		for _, test := range pkg.Tests {
+			gotOutput = append(gotOutput, test.Output...)
+			gotStderr = append(gotStderr, test.Stderr...)
+		}
+	}
+	if diff := cmp.Diff([]string{"log message"}, gotStderr); diff != "" {
+		t.Errorf("ParseStreams stderr incorrect, diff (-want, +got):\n%s", diff)
+	}
+	for _, line := range gotOutput {
+		if line == "log message" {
+			t.Errorf("ParseStreams added stderr to output: %q", gotOutput)
+		}
+	}
+}
+
+func TestParseOutputDir(t *testing.T) {
+	files, err := filepath.Glob("../../testdata/*.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			data, err := ioutil.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want, err := NewParser(TimestampFunc(testTimestampFunc)).Parse(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+
+			parser := NewParser(TimestampFunc(testTimestampFunc), OutputDir(""), DiscardEvents())
+			got, err := parser.Parse(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("Parse with OutputDir failed: %v", err)
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("Parse with OutputDir returned a different report, diff (-want, +got):\n%s", diff)
+			}
+			if events := parser.Events(); events != nil {
+				t.Errorf("Events returned %d events, want nil when using DiscardEvents", len(events))
+			}
+		})
+	}
+}
+
+func TestParsePackageFunc(t *testing.T) {
+	r, w := io.Pipe()
+	called := make(chan string, 2)
+	parser := NewParser(OutputDir(""), PackageFunc(func(pkg *gtr.Package) {
+		pkg.Tests[0].Output = nil
+		called <- pkg.Name
+	}))
+
+	go func() {
+		fmt.Fprint(w, "=== RUN   TestA\noutput a\n--- PASS: TestA (0.00s)\nok  \tpackage/a\t0.100s\n")
+		// The second package is only written once the first has been passed
+		// to the PackageFunc.
+		<-called
+		fmt.Fprint(w, "=== RUN   TestB\noutput b\n--- PASS: TestB (0.00s)\nok  \tpackage/b\t0.100s\n")
+		w.Close()
+	}()
+
+	report, err := parser.Parse(r)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if got := <-called; got != "package/b" {
+		t.Errorf("PackageFunc called for %q, want package/b", got)
+	}
+	if len(report.Packages) != 2 {
+		t.Fatalf("Parse returned %d packages, want 2", len(report.Packages))
+	}
+	for _, pkg := range report.Packages {
+		if output := pkg.Tests[0].Output; output != nil {
+			t.Errorf("package %s has test output %q, want it removed by PackageFunc", pkg.Name, output)
+		}
+	}
+}
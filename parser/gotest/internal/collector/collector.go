@@ -1,27 +1,33 @@
 // Package collector collects output lines grouped by id and provides ways to
-// retrieve and merge output ordered by the time each line was added.
+// retrieve and merge output ordered by the order in which lines were added.
 package collector
 
 import (
 	"sort"
-	"time"
 )
 
-// line is a single line of output captured at some point in time.
+// line is a single line of output.
 type line struct {
-	Timestamp time.Time
-	Text      string
+	Seq    uint64 // sequence number, in the order lines were added
+	Text   string
+	Stderr bool // true if the line was written to stderr
+
+	// offset and length of Text in the FileStore, if this Output is backed by
+	// a file.
+	offset int64
+	length int
 }
 
 // Output stores output lines grouped by id. Output can be retrieved for one or
 // more ids and output for different ids can be merged together, while
-// preserving their insertion original order based on the time it was
-// collected.
+// preserving their original insertion order.
 // Output also tracks the active id, so you can append output without providing
 // an id.
 type Output struct {
-	m  map[int][]line
-	id int // active id
+	m     map[int][]line
+	id    int        // active id
+	seq   uint64     // sequence number of the next line
+	store *FileStore // optional store for the text of each line
 }
 
 // New returns a new output collector.
@@ -29,6 +35,35 @@ func New() *Output {
 	return &Output{m: make(map[int][]line)}
 }
 
+// NewFileBacked returns a new output collector that keeps the text of each
+// line in the given FileStore instead of in memory. Only an index of the
+// lines for each id is kept in memory.
+func NewFileBacked(store *FileStore) *Output {
+	return &Output{m: make(map[int][]line), store: store}
+}
+
+// newLine returns a line for text, storing its text in the FileStore if
+// there is one.
+func (o *Output) newLine(text string, stderr bool) line {
+	l := line{Seq: o.seq, Stderr: stderr}
+	o.seq++
+	if o.store == nil {
+		l.Text = text
+		return l
+	}
+	l.offset = o.store.write(text)
+	l.length = len(text)
+	return l
+}
+
+// text returns the text of l.
+func (o *Output) text(l line) string {
+	if o.store == nil {
+		return l.Text
+	}
+	return o.store.read(l.offset, l.length)
+}
+
 // Clear deletes all output for the given id.
 func (o *Output) Clear(id int) {
 	delete(o.m, id)
@@ -37,12 +72,18 @@ func (o *Output) Clear(id int) {
 // Append appends the given line of text to the output of the currently active
 // id.
 func (o *Output) Append(text string) {
-	o.m[o.id] = append(o.m[o.id], line{time.Now(), text})
+	o.m[o.id] = append(o.m[o.id], o.newLine(text, false))
+}
+
+// AppendStderr appends the given line of text, which was written to stderr, to
+// the output of the currently active id.
+func (o *Output) AppendStderr(text string) {
+	o.m[o.id] = append(o.m[o.id], o.newLine(text, true))
 }
 
 // AppendToID appends the given line of text to the output of the given id.
 func (o *Output) AppendToID(id int, text string) {
-	o.m[id] = append(o.m[id], line{time.Now(), text})
+	o.m[id] = append(o.m[id], o.newLine(text, false))
 }
 
 // Contains returns true if any output lines were collected for the given id.
@@ -54,37 +95,55 @@ func (o *Output) Contains(id int) bool {
 func (o *Output) Get(id int) []string {
 	var lines []string
 	for _, line := range o.m[id] {
-		lines = append(lines, line.Text)
+		lines = append(lines, o.text(line))
 	}
 	return lines
 }
 
-// GetAll returns the output lines for all ids sorted by the collection
-// timestamp of each line of output.
+// GetAll returns the output lines for all ids sorted by the order in which
+// they were added.
 func (o *Output) GetAll(ids ...int) []string {
+	var lines []string
+	for _, line := range o.sorted(ids) {
+		lines = append(lines, o.text(line))
+	}
+	return lines
+}
+
+// GetAllStreams returns the output lines for all ids sorted by the order in
+// which they were added, split into the lines that were written to
+// stdout and the lines that were written to stderr.
+func (o *Output) GetAllStreams(ids ...int) (stdout, stderr []string) {
+	for _, line := range o.sorted(ids) {
+		if line.Stderr {
+			stderr = append(stderr, o.text(line))
+		} else {
+			stdout = append(stdout, o.text(line))
+		}
+	}
+	return stdout, stderr
+}
+
+func (o *Output) sorted(ids []int) []line {
 	var output []line
 	for _, id := range ids {
 		output = append(output, o.m[id]...)
 	}
 	sort.Slice(output, func(i, j int) bool {
-		return output[i].Timestamp.Before(output[j].Timestamp)
+		return output[i].Seq < output[j].Seq
 	})
-	var lines []string
-	for _, line := range output {
-		lines = append(lines, line.Text)
-	}
-	return lines
+	return output
 }
 
 // Merge merges the output lines from fromID into intoID, and sorts the output
-// by the collection timestamp of each line of output.
+// by the order in which each line was added.
 func (o *Output) Merge(fromID, intoID int) {
 	var merged []line
 	for _, id := range []int{fromID, intoID} {
 		merged = append(merged, o.m[id]...)
 	}
 	sort.Slice(merged, func(i, j int) bool {
-		return merged[i].Timestamp.Before(merged[j].Timestamp)
+		return merged[i].Seq < merged[j].Seq
 	})
 	o.m[intoID] = merged
 	delete(o.m, fromID)
@@ -95,3 +154,8 @@ func (o *Output) Merge(fromID, intoID int) {
 func (o *Output) SetActiveID(id int) {
 	o.id = id
 }
+
+// ActiveID returns the active id.
+func (o *Output) ActiveID() int {
+	return o.id
+}
@@ -69,6 +69,30 @@ func TestGetAll(t *testing.T) {
 	}
 }
 
+func TestGetAllStreams(t *testing.T) {
+	o := New()
+	o.SetActiveID(1)
+	o.Append("1")
+	o.AppendStderr("2")
+	o.SetActiveID(2)
+	o.AppendStderr("3")
+	o.Append("4")
+
+	wantStdout, wantStderr := []string{"1", "4"}, []string{"2", "3"}
+	stdout, stderr := o.GetAllStreams(1, 2)
+	if diff := cmp.Diff(wantStdout, stdout); diff != "" {
+		t.Errorf("GetAllStreams(1, 2) stdout incorrect (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(wantStderr, stderr); diff != "" {
+		t.Errorf("GetAllStreams(1, 2) stderr incorrect (-want +got):\n%s", diff)
+	}
+
+	want := []string{"1", "2", "3", "4"}
+	if diff := cmp.Diff(want, o.GetAll(1, 2)); diff != "" {
+		t.Errorf("GetAll(1, 2) incorrect (-want +got):\n%s", diff)
+	}
+}
+
 func TestMerge(t *testing.T) {
 	o := New()
 	for i := 1; i <= 10; i++ {
@@ -115,3 +139,36 @@ func TestActiveID(t *testing.T) {
 	}
 
 }
+
+func TestFileBacked(t *testing.T) {
+	store, err := NewFileStore("")
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+	defer store.Close()
+
+	o := NewFileBacked(store)
+	for i := 1; i <= 10; i++ {
+		o.AppendToID(i%3, strconv.Itoa(i))
+	}
+	o.SetActiveID(1)
+	o.AppendStderr("stderr")
+
+	want := []string{"1", "4", "7", "10", "stderr"}
+	if diff := cmp.Diff(want, o.Get(1)); diff != "" {
+		t.Errorf("Get(1) incorrect (-want +got):\n%s", diff)
+	}
+
+	o.Merge(2, 1)
+	wantStdout := []string{"1", "2", "4", "5", "7", "8", "10"}
+	stdout, stderr := o.GetAllStreams(1)
+	if diff := cmp.Diff(wantStdout, stdout); diff != "" {
+		t.Errorf("GetAllStreams(1) stdout incorrect (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"stderr"}, stderr); diff != "" {
+		t.Errorf("GetAllStreams(1) stderr incorrect (-want +got):\n%s", diff)
+	}
+	if err := store.Err(); err != nil {
+		t.Errorf("FileStore returned error: %v", err)
+	}
+}
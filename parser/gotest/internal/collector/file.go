@@ -0,0 +1,61 @@
+package collector
+
+import (
+	"io/ioutil"
+	"os"
+)
+
+// FileStore stores the text of output lines in a temporary file. A single
+// FileStore can be shared by multiple Outputs. Errors that occur while writing
+// or reading the file are recorded and can be retrieved using Err.
+type FileStore struct {
+	f    *os.File
+	size int64
+	err  error
+}
+
+// NewFileStore creates a FileStore backed by a new temporary file in dir. If
+// dir is empty, the default directory for temporary files is used. The file is
+// removed when the FileStore is closed.
+func NewFileStore(dir string) (*FileStore, error) {
+	f, err := ioutil.TempFile(dir, "go-junit-report-*.out")
+	if err != nil {
+		return nil, err
+	}
+	return &FileStore{f: f}, nil
+}
+
+// write appends text to the file and returns its offset.
+func (s *FileStore) write(text string) int64 {
+	offset := s.size
+	n, err := s.f.WriteString(text)
+	s.size += int64(n)
+	if err != nil && s.err == nil {
+		s.err = err
+	}
+	return offset
+}
+
+// read returns length bytes of text starting at offset.
+func (s *FileStore) read(offset int64, length int) string {
+	buf := make([]byte, length)
+	if _, err := s.f.ReadAt(buf, offset); err != nil && s.err == nil {
+		s.err = err
+	}
+	return string(buf)
+}
+
+// Err returns the first error that occurred while writing to or reading from
+// the file.
+func (s *FileStore) Err() error {
+	return s.err
+}
+
+// Close closes and removes the file.
+func (s *FileStore) Close() error {
+	err := s.f.Close()
+	if rerr := os.Remove(s.f.Name()); err == nil {
+		err = rerr
+	}
+	return err
+}
@@ -17,6 +17,7 @@ type LineReader interface {
 // Metadata contains metadata that belongs to a line.
 type Metadata struct {
 	Package string
+	Stderr  bool // true if the line was written to stderr
 }
 
 // LimitedLineReader reads lines from an io.Reader object with a configurable
@@ -120,3 +121,81 @@ func (r *JSONEventReader) ReadLine() (string, *Metadata, error) {
 		return strings.TrimSuffix(event.Output, "\n"), &Metadata{Package: event.Package}, nil
 	}
 }
+
+// StreamLineReader reads lines from the stdout and stderr streams of a
+// process concurrently, and returns them in the order in which they were
+// read. The Metadata of lines read from stderr has Stderr set. Since both
+// streams are read independently, the relative order of lines that were
+// written at almost the same time is not guaranteed.
+type StreamLineReader struct {
+	lines chan streamLine
+	done  chan struct{} // closed by Close to stop reading
+	open  int           // number of streams that have not reached EOF yet
+}
+
+type streamLine struct {
+	line     string
+	metadata *Metadata
+	err      error
+}
+
+var _ LineReader = &StreamLineReader{}
+
+// NewStreamLineReader returns a StreamLineReader that reads lines from the
+// given stdout and stderr LineReaders. Close must be called when the caller
+// stops reading before both streams have been read completely.
+func NewStreamLineReader(stdout, stderr LineReader) *StreamLineReader {
+	r := &StreamLineReader{lines: make(chan streamLine), done: make(chan struct{}), open: 2}
+	go r.read(stdout, false)
+	go r.read(stderr, true)
+	return r
+}
+
+func (r *StreamLineReader) read(lr LineReader, stderr bool) {
+	for {
+		line, metadata, err := lr.ReadLine()
+		if err == nil && stderr {
+			m := &Metadata{Stderr: true}
+			if metadata != nil {
+				m.Package = metadata.Package
+			}
+			metadata = m
+		}
+		select {
+		case r.lines <- streamLine{line, metadata, err}:
+		case <-r.done:
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// ReadLine returns the next line from either stream. It returns io.EOF once
+// both streams have been read completely.
+func (r *StreamLineReader) ReadLine() (string, *Metadata, error) {
+	for r.open > 0 {
+		l := <-r.lines
+		if l.err != nil {
+			r.open--
+			if l.err == io.EOF {
+				continue
+			}
+		}
+		return l.line, l.metadata, l.err
+	}
+	return "", nil, io.EOF
+}
+
+// Close stops reading from the streams. Lines that have not been returned by
+// ReadLine yet are discarded. A stream that is blocked in a read is no longer
+// read from once that read returns.
+func (r *StreamLineReader) Close() error {
+	select {
+	case <-r.done:
+	default:
+		close(r.done)
+	}
+	return nil
+}
@@ -2,9 +2,12 @@ package reader
 
 import (
 	"bufio"
+	"errors"
 	"io"
+	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 )
@@ -99,3 +102,61 @@ func TestJSONEventReader(t *testing.T) {
 		}
 	}
 }
+
+func TestStreamLineReader(t *testing.T) {
+	stdout := NewLimitedLineReader(strings.NewReader("out 1\nout 2\n"), testingLimit)
+	stderr := NewLimitedLineReader(strings.NewReader("err 1\n"), testingLimit)
+	r := NewStreamLineReader(stdout, stderr)
+
+	var gotStdout, gotStderr []string
+	for {
+		line, metadata, err := r.ReadLine()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("ReadLine() returned error %v", err)
+		}
+		if metadata != nil && metadata.Stderr {
+			gotStderr = append(gotStderr, line)
+		} else {
+			gotStdout = append(gotStdout, line)
+		}
+	}
+
+	if diff := cmp.Diff([]string{"out 1", "out 2"}, gotStdout); diff != "" {
+		t.Errorf("ReadLine() stdout lines incorrect (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"err 1"}, gotStderr); diff != "" {
+		t.Errorf("ReadLine() stderr lines incorrect (-want +got):\n%s", diff)
+	}
+}
+
+func TestStreamLineReaderClose(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	stdout := NewLimitedLineReader(strings.NewReader(strings.Repeat("out\n", 100)), testingLimit)
+	stderr := NewLimitedLineReader(errReader{}, testingLimit)
+	r := NewStreamLineReader(stdout, stderr)
+	for {
+		if _, _, err := r.ReadLine(); err != nil {
+			break
+		}
+	}
+	r.Close()
+
+	// Both goroutines should stop, even though the stdout lines were never
+	// read.
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("StreamLineReader goroutines still running after Close, got %d goroutines, want %d", runtime.NumGoroutine(), before)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+type errReader struct{}
+
+func (errReader) Read(p []byte) (int, error) {
+	return 0, errors.New("read error")
+}
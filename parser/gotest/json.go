@@ -23,6 +23,18 @@ func (p *JSONParser) Parse(r io.Reader) (gtr.Report, error) {
 	return p.gp.parse(reader.NewJSONEventReader(r))
 }
 
+// ParseStreams parses Go test json output from stdout and plain text output
+// from stderr, which are read concurrently, and returns gtr.Report. Output
+// that was written to stderr is reported separately from other output.
+func (p *JSONParser) ParseStreams(stdout, stderr io.Reader) (gtr.Report, error) {
+	r := reader.NewStreamLineReader(
+		reader.NewJSONEventReader(stdout),
+		reader.NewLimitedLineReader(stderr, maxLineSize),
+	)
+	defer r.Close()
+	return p.gp.parse(r)
+}
+
 // Events returns the events created by the parser.
 func (p *JSONParser) Events() []Event {
 	return p.gp.Events()
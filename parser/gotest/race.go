@@ -0,0 +1,178 @@
+package gotest
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/jstemmer/go-junit-report/v2/gtr"
+)
+
+const (
+	raceKey = "gotest.race"
+)
+
+var (
+	regexRaceAccess    = regexp.MustCompile(`^((?:Previous )?(?:[Ww]rite|[Rr]ead))(?: \(by atomic\))? at (\S+) by (?:goroutine (\d+)|main goroutine):$`)
+	regexRaceGoroutine = regexp.MustCompile(`^Goroutine (\d+) \(([^)]+)\) created at:$`)
+	regexRaceFunction  = regexp.MustCompile(`^  (\S.*)$`)
+	regexRaceFile      = regexp.MustCompile(`^\s+(\S+):(\d+)(?: \+0x[0-9a-f]+)?$`)
+)
+
+// Race contains a data race report printed by the race detector and is
+// intended to be used as extra data in a gtr.Test.
+type Race struct {
+	// Accesses contains the conflicting memory accesses, usually the current
+	// access followed by the previous one.
+	Accesses []RaceAccess
+
+	// Goroutines contains the stacks where the goroutines involved in the
+	// race were created.
+	Goroutines []RaceGoroutine
+}
+
+// RaceAccess is a single memory access reported by the race detector.
+type RaceAccess struct {
+	Op        string // e.g. "Write" or "Previous read"
+	Addr      string
+	Goroutine int
+	Stack     []StackFrame
+}
+
+// RaceGoroutine describes where a goroutine involved in a data race was
+// created.
+type RaceGoroutine struct {
+	ID    int
+	State string
+	Stack []StackFrame
+}
+
+// StackFrame is a single function call in a stack trace.
+type StackFrame struct {
+	Function string
+	File     string
+	Line     int
+}
+
+// Location returns the base name of the file and the line number of this
+// frame, or an empty string if the file is unknown.
+func (f StackFrame) Location() string {
+	if f.File == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", path.Base(f.File), f.Line)
+}
+
+// Message returns a short description of the race, listing the conflicting
+// accesses and their locations.
+func (r Race) Message() string {
+	var accesses []string
+	for _, a := range r.Accesses {
+		desc := fmt.Sprintf("%s by goroutine %d", strings.ToLower(a.Op), a.Goroutine)
+		if len(a.Stack) > 0 && a.Stack[0].File != "" {
+			desc += " at " + a.Stack[0].Location()
+		}
+		accesses = append(accesses, desc)
+	}
+	if len(accesses) == 0 {
+		return "Data race"
+	}
+	return "Data race: " + strings.Join(accesses, ", ")
+}
+
+// functions returns the functions in the stacks of the accesses, followed by
+// the functions in the stacks of the goroutines.
+func (r Race) functions() []string {
+	var functions []string
+	for _, a := range r.Accesses {
+		for _, f := range a.Stack {
+			functions = append(functions, f.Function)
+		}
+	}
+	for _, g := range r.Goroutines {
+		for _, f := range g.Stack {
+			functions = append(functions, f.Function)
+		}
+	}
+	return functions
+}
+
+// raceParser builds a Race from the lines of a data race report.
+type raceParser struct {
+	race Race
+
+	// section is the kind of stack currently being parsed: raceAccess,
+	// raceGoroutine or raceNone.
+	section int
+}
+
+const (
+	raceNone = iota
+	raceAccess
+	raceGoroutine
+)
+
+// parseLine parses a single line of a data race report, between the `WARNING:
+// DATA RACE` line and the closing separator.
+func (p *raceParser) parseLine(line string) {
+	if matches := regexRaceAccess.FindStringSubmatch(line); len(matches) == 4 {
+		p.race.Accesses = append(p.race.Accesses, RaceAccess{
+			Op:        matches[1],
+			Addr:      matches[2],
+			Goroutine: int(parseInt(matches[3])),
+		})
+		p.section = raceAccess
+	} else if matches := regexRaceGoroutine.FindStringSubmatch(line); len(matches) == 3 {
+		p.race.Goroutines = append(p.race.Goroutines, RaceGoroutine{
+			ID:    int(parseInt(matches[1])),
+			State: matches[2],
+		})
+		p.section = raceGoroutine
+	} else if matches := regexRaceFile.FindStringSubmatch(line); len(matches) == 3 {
+		if stack := p.stack(); stack != nil && len(*stack) > 0 {
+			frame := &(*stack)[len(*stack)-1]
+			frame.File = matches[1]
+			frame.Line = int(parseInt(matches[2]))
+		}
+	} else if matches := regexRaceFunction.FindStringSubmatch(line); len(matches) == 2 {
+		if stack := p.stack(); stack != nil {
+			*stack = append(*stack, StackFrame{Function: matches[1]})
+		}
+	} else {
+		p.section = raceNone
+	}
+}
+
+// stack returns the stack that is currently being parsed, or nil if there is
+// none.
+func (p *raceParser) stack() *[]StackFrame {
+	switch p.section {
+	case raceAccess:
+		return &p.race.Accesses[len(p.race.Accesses)-1].Stack
+	case raceGoroutine:
+		return &p.race.Goroutines[len(p.race.Goroutines)-1].Stack
+	}
+	return nil
+}
+
+// GetRaceData is a helper function that returns the data races contained in
+// the data field of the given gtr.Test t. If no (valid) data races are
+// present, ok will be set to false.
+func GetRaceData(t gtr.Test) (races []Race, ok bool) {
+	if t.Data != nil {
+		if data, exists := t.Data[raceKey]; exists {
+			races, ok := data.([]Race)
+			return races, ok
+		}
+	}
+	return nil, false
+}
+
+// SetRaceData is a helper function that writes the data races to the data
+// field of the given gtr.Test t.
+func SetRaceData(t *gtr.Test, races []Race) {
+	if t.Data != nil {
+		t.Data[raceKey] = races
+	}
+}
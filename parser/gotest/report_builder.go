@@ -2,7 +2,9 @@ package gotest
 
 import (
 	"fmt"
+	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -14,6 +16,16 @@ const (
 	globalID = 0
 )
 
+var (
+	// regexStackFrame captures the name of a Test, Benchmark, Fuzz or Example
+	// function in a line of a goroutine stack trace.
+	regexStackFrame = regexp.MustCompile(`^\S*?\.((?:Test|Benchmark|Fuzz|Example)\w*)(?:\.func[\d.]+)?\(`)
+
+	// regexFailureLocation captures the file, line and message of output
+	// printed by t.Error, t.Fatal and similar functions.
+	regexFailureLocation = regexp.MustCompile(`^\s+([^\s:]+\.go):(\d+): (.*)$`)
+)
+
 // reportBuilder helps build a test Report from a collection of events.
 //
 // The reportBuilder delegates to the packageBuilder for creating packages from
@@ -27,14 +39,16 @@ type reportBuilder struct {
 	packageBuilders map[string]*packageBuilder
 	buildErrors     map[int]gtr.Error
 
-	nextID   int               // next free unused id
-	output   *collector.Output // output collected for each id
-	packages []gtr.Package     // completed packages
+	nextID   int                  // next free unused id
+	output   *collector.Output    // output collected for each id
+	store    *collector.FileStore // optional file to store output in
+	packages []gtr.Package        // completed packages
 
 	// options
 	packageName   string
 	subtestMode   SubtestMode
 	timestampFunc func() time.Time
+	packageFunc   func(pkg *gtr.Package)
 }
 
 // newReportBuilder creates a new reportBuilder.
@@ -55,7 +69,7 @@ func (b *reportBuilder) getPackageBuilder(packageName string) *packageBuilder {
 	if !ok {
 		output := b.output
 		if packageName != "" {
-			output = collector.New()
+			output = b.newOutput()
 		}
 		pb = newPackageBuilder(b.generateID, output)
 		b.packageBuilders[packageName] = pb
@@ -63,8 +77,21 @@ func (b *reportBuilder) getPackageBuilder(packageName string) *packageBuilder {
 	return pb
 }
 
+// newOutput returns a new output collector, which stores output in the
+// FileStore if one was configured.
+func (b *reportBuilder) newOutput() *collector.Output {
+	if b.store != nil {
+		return collector.NewFileBacked(b.store)
+	}
+	return collector.New()
+}
+
 // ProcessEvent takes a test event and adds it to the report.
 func (b *reportBuilder) ProcessEvent(ev Event) {
+	if ev.Redactions > 0 {
+		b.getPackageBuilder(ev.Package).redactions += ev.Redactions
+	}
+
 	switch ev.Type {
 	case "run_test":
 		b.getPackageBuilder(ev.Package).CreateTest(ev.Name)
@@ -77,22 +104,42 @@ func (b *reportBuilder) ProcessEvent(ev Event) {
 	case "run_benchmark":
 		b.getPackageBuilder(ev.Package).CreateTest(ev.Name)
 	case "benchmark":
-		b.getPackageBuilder(ev.Package).BenchmarkResult(ev.Name, ev.Iterations, ev.NsPerOp, ev.MBPerSec, ev.BytesPerOp, ev.AllocsPerOp)
+		b.getPackageBuilder(ev.Package).BenchmarkResult(ev.Name, ev.CPU, ev.Iterations, ev.NsPerOp, ev.MBPerSec, ev.BytesPerOp, ev.AllocsPerOp, ev.Metrics)
 	case "end_benchmark":
-		b.getPackageBuilder(ev.Package).EndTest(ev.Name, ev.Result, 0, 0)
+		b.getPackageBuilder(ev.Package).EndBenchmark(ev.Name, ev.Result, ev.CPU)
+	case "benchmark_header":
+		b.getPackageBuilder(ev.Package).BenchmarkHeader(ev.Name, ev.Data, ev.Stderr)
+	case "race":
+		b.getPackageBuilder(ev.Package).DataRace(*ev.Race)
+	case "race_detected":
+		b.getPackageBuilder(ev.Package).RaceDetected()
+	case "example_output":
+		b.getPackageBuilder(ev.Package).ExampleOutput()
+	case "panic":
+		b.getPackageBuilder(ev.Package).Panic(ev.Data)
+	case "timeout":
+		b.getPackageBuilder(ev.Package).Timeout(ev.Data)
+	case "timeout_test":
+		b.getPackageBuilder(ev.Package).TimeoutTest(ev.Name, ev.Duration)
+	case "fuzz_progress":
+		b.getPackageBuilder(ev.Package).FuzzProgress(ev.Duration, ev.FuzzExecs, ev.FuzzNewInteresting, ev.FuzzTotalInteresting)
+	case "fuzz_failing_input":
+		b.getPackageBuilder(ev.Package).FuzzFailingInput(ev.Name, ev.Data)
 	case "status":
 		b.getPackageBuilder(ev.Package).End()
 	case "summary":
 		// The summary marks the end of a package. We can now create the actual
 		// package from all the events we've processed so far for this package.
-		b.packages = append(b.packages, b.CreatePackage(ev.Package, ev.Name, ev.Result, ev.Duration, ev.Data))
+		b.addPackage(b.CreatePackage(ev.Package, ev.Name, ev.Result, ev.Duration, ev.Data))
 	case "coverage":
 		b.getPackageBuilder(ev.Package).Coverage(ev.CovPct, ev.CovPackages)
 	case "build_output":
 		b.CreateBuildError(ev.Name)
 	case "output":
 		if ev.Package != "" {
-			b.getPackageBuilder(ev.Package).Output(ev.Data)
+			b.getPackageBuilder(ev.Package).Output(ev.Data, ev.Stderr)
+		} else if ev.Stderr {
+			b.output.AppendStderr(ev.Data)
 		} else {
 			b.output.Append(ev.Data)
 		}
@@ -113,21 +160,39 @@ func (b *reportBuilder) generateID() int {
 // Build returns the new Report containing all the tests, build errors and
 // their output created from the processed events.
 func (b *reportBuilder) Build() gtr.Report {
-	// Create packages for any leftover package builders.
-	for name, pb := range b.packageBuilders {
-		if pb.IsEmpty() {
+	// Create packages for any leftover package builders, sorted by name.
+	var names []string
+	for name := range b.packageBuilders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if b.packageBuilders[name].IsEmpty() {
 			continue
 		}
-		b.packages = append(b.packages, b.CreatePackage(name, b.packageName, "", 0, ""))
+		b.addPackage(b.CreatePackage(name, b.packageName, "", 0, ""))
 	}
 
-	// Create packages for any leftover build errors.
-	for _, buildErr := range b.buildErrors {
-		b.packages = append(b.packages, b.CreatePackage("", buildErr.Name, "", 0, ""))
+	// Create packages for any leftover build errors, in the order in which
+	// they were created.
+	for _, id := range b.buildErrorIDs() {
+		if buildErr, ok := b.buildErrors[id]; ok {
+			b.addPackage(b.CreatePackage("", buildErr.Name, "", 0, ""))
+		}
 	}
 	return gtr.Report{Packages: b.packages}
 }
 
+// buildErrorIDs returns the ids of all build errors in ascending order.
+func (b *reportBuilder) buildErrorIDs() []int {
+	var ids []int
+	for id := range b.buildErrors {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
 // CreateBuildError creates a new build error and marks it as active.
 func (b *reportBuilder) CreateBuildError(packageName string) {
 	id := b.generateID()
@@ -135,6 +200,15 @@ func (b *reportBuilder) CreateBuildError(packageName string) {
 	b.buildErrors[id] = gtr.Error{ID: id, Name: packageName}
 }
 
+// addPackage adds pkg to the completed packages, after passing it to the
+// packageFunc if there is one.
+func (b *reportBuilder) addPackage(pkg gtr.Package) {
+	if b.packageFunc != nil {
+		b.packageFunc(&pkg)
+	}
+	b.packages = append(b.packages, pkg)
+}
+
 // CreatePackage returns a new package containing all the build errors, output,
 // tests and benchmarks created so far. The optional packageName is used to
 // find the correct reportBuilder. The newPackageName is the actual package
@@ -149,7 +223,8 @@ func (b *reportBuilder) CreatePackage(packageName, newPackageName, result string
 
 	// First check if this package contained a build error. If that's the case,
 	// we won't find any tests in this package.
-	for id, buildErr := range b.buildErrors {
+	for _, id := range b.buildErrorIDs() {
+		buildErr := b.buildErrors[id]
 		if buildErr.Name == newPackageName || strings.TrimSuffix(buildErr.Name, "_test") == newPackageName {
 			pkg.BuildError = buildErr
 			pkg.BuildError.ID = id
@@ -168,6 +243,11 @@ func (b *reportBuilder) CreatePackage(packageName, newPackageName, result string
 	pb := b.getPackageBuilder(packageName)
 	delete(b.packageBuilders, packageName)
 	pb.output.SetActiveID(0)
+	pkg.Properties = pb.properties
+	if pb.redactions > 0 {
+		pkg.AddProperty("redaction.count", strconv.Itoa(pb.redactions))
+	}
+	panicOutput := pb.attachPanics()
 
 	// If the packageBuilder is empty, we never received any events for this
 	// package so there's no need to continue.
@@ -191,7 +271,7 @@ func (b *reportBuilder) CreatePackage(packageName, newPackageName, result string
 				Output: pb.output.Get(globalID),
 			}
 		} else {
-			pkg.Output = pb.output.Get(globalID)
+			pkg.Output, pkg.Stderr = pb.output.GetAllStreams(globalID)
 		}
 		pb.output.Clear(globalID)
 		return pkg
@@ -218,10 +298,9 @@ func (b *reportBuilder) CreatePackage(packageName, newPackageName, result string
 				continue
 			}
 		}
-		t.Output = pb.output.Get(id)
+		t.Output, t.Stderr = pb.output.GetAllStreams(append([]int{id}, panicOutput[id]...)...)
 		tests = append(tests, t)
 	}
-	tests = groupBenchmarksByName(tests, b.output)
 
 	// Sort packages by id to ensure we maintain insertion order.
 	sort.Slice(tests, func(i, j int) bool {
@@ -229,12 +308,72 @@ func (b *reportBuilder) CreatePackage(packageName, newPackageName, result string
 	})
 
 	pkg.Tests = groupBenchmarksByName(tests, pb.output)
+	for i := range pkg.Tests {
+		if bench, ok := GetBenchmarkData(pkg.Tests[i]); ok {
+			pkg.Tests[i].Properties = bench.Properties()
+		}
+		if fuzz, ok := GetFuzzData(pkg.Tests[i]); ok {
+			pkg.Tests[i].Properties = fuzz.Properties()
+		}
+		if races, ok := GetRaceData(pkg.Tests[i]); ok && len(races) > 0 {
+			if t := &pkg.Tests[i]; t.Result == gtr.Fail && t.Failure.Type == "" {
+				t.Failure = gtr.Failure{Type: "race", Message: races[0].Message()}
+			}
+		}
+		if t := &pkg.Tests[i]; t.Kind == gtr.KindExample && t.Result == gtr.Fail {
+			if example, start, ok := parseExampleOutput(t.Output); ok {
+				SetExampleData(t, example)
+				t.Output = append(append([]string{}, t.Output[:start]...), example.Diff()...)
+				t.Failure = gtr.Failure{Type: "example", Message: "Example output mismatch"}
+			}
+		}
+		if t := &pkg.Tests[i]; t.Result == gtr.Fail && t.Failure == (gtr.Failure{}) {
+			t.Failure = findFailureLocation(t.Output)
+		}
+	}
 	pkg.Coverage = pb.coverage
-	pkg.Output = pb.output.Get(globalID)
+	pkg.HasCoverage = pb.covered
+	pkg.Output, pkg.Stderr = pb.output.GetAllStreams(globalID)
 	pb.output.Clear(globalID)
 	return pkg
 }
 
+// findFailureLocation returns a gtr.Failure containing the location and
+// message of the first line in output that looks like it was printed by
+// t.Error, t.Fatal or similar functions. The indented lines that follow it
+// are the continuation of a multi-line message and are added to the message.
+func findFailureLocation(output []string) gtr.Failure {
+	for i, line := range output {
+		matches := regexFailureLocation.FindStringSubmatch(line)
+		if len(matches) != 4 {
+			continue
+		}
+		message := matches[3]
+		indent := leadingSpace(line)
+		var prefix string
+		for _, next := range output[i+1:] {
+			if len(leadingSpace(next)) <= len(indent) || strings.TrimSpace(next) == "" {
+				break
+			}
+			if prefix == "" {
+				prefix = leadingSpace(next)
+			}
+			message += "\n" + strings.TrimPrefix(next, prefix)
+		}
+		return gtr.Failure{
+			Message: message,
+			File:    matches[1],
+			Line:    int(parseInt(matches[2])),
+		}
+	}
+	return gtr.Failure{}
+}
+
+// leadingSpace returns the leading whitespace of line.
+func leadingSpace(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
 // parseResult returns a gtr.Result for the given result string r.
 func parseResult(r string) gtr.Result {
 	switch r {
@@ -252,14 +391,23 @@ func parseResult(r string) gtr.Result {
 }
 
 // groupBenchmarksByName groups tests with the Benchmark prefix if they have
-// the same name and combines their output.
+// the same name and ran with the same GOMAXPROCS value, and combines their
+// output. If a benchmark ran with different GOMAXPROCS values, the grouped
+// benchmarks will be named using the `-N` suffix that go test uses.
 func groupBenchmarksByName(tests []gtr.Test, output *collector.Output) []gtr.Test {
 	if len(tests) == 0 {
 		return nil
 	}
 
+	type benchmarkKey struct {
+		name string
+		cpu  int
+	}
+
 	var grouped []gtr.Test
-	byName := make(map[string][]gtr.Test)
+	keys := make(map[int]benchmarkKey)
+	byKey := make(map[benchmarkKey][]gtr.Test)
+	cpus := make(map[string]map[int]struct{})
 	for _, test := range tests {
 		if !strings.HasPrefix(test.Name, "Benchmark") {
 			// If this test is not a benchmark, we won't group it by name but
@@ -267,22 +415,32 @@ func groupBenchmarksByName(tests []gtr.Test, output *collector.Output) []gtr.Tes
 			grouped = append(grouped, test)
 			continue
 		}
-		if _, ok := byName[test.Name]; !ok {
+		bench, _ := GetBenchmarkData(test)
+		key := benchmarkKey{test.Name, bench.CPU}
+		if _, ok := byKey[key]; !ok {
+			keys[len(grouped)] = key
 			grouped = append(grouped, gtr.NewTest(test.ID, test.Name))
 		}
-		byName[test.Name] = append(byName[test.Name], test)
+		byKey[key] = append(byKey[key], test)
+
+		if cpus[test.Name] == nil {
+			cpus[test.Name] = make(map[int]struct{})
+		}
+		cpus[test.Name][bench.CPU] = struct{}{}
 	}
 
 	for i, group := range grouped {
-		if !strings.HasPrefix(group.Name, "Benchmark") {
+		key, ok := keys[i]
+		if !ok {
 			continue
 		}
 		var (
-			ids   []int
-			total Benchmark
-			count int
+			ids          []int
+			total        = Benchmark{CPU: key.cpu}
+			count        int
+			metricCounts = make(map[string]int)
 		)
-		for _, test := range byName[group.Name] {
+		for _, test := range byKey[key] {
 			ids = append(ids, test.ID)
 			if test.Result != gtr.Pass {
 				continue
@@ -294,19 +452,32 @@ func groupBenchmarksByName(tests []gtr.Test, output *collector.Output) []gtr.Tes
 				total.MBPerSec += bench.MBPerSec
 				total.BytesPerOp += bench.BytesPerOp
 				total.AllocsPerOp += bench.AllocsPerOp
+				for unit, value := range bench.Metrics {
+					if total.Metrics == nil {
+						total.Metrics = make(map[string]float64)
+					}
+					total.Metrics[unit] += value
+					metricCounts[unit]++
+				}
 				count++
 			}
 		}
 
-		group.Duration = combinedDuration(byName[group.Name])
-		group.Result = groupResults(byName[group.Name])
-		group.Output = output.GetAll(ids...)
+		if len(cpus[key.name]) > 1 && key.cpu > 0 {
+			group.Name = fmt.Sprintf("%s-%d", key.name, key.cpu)
+		}
+		group.Duration = combinedDuration(byKey[key])
+		group.Result = groupResults(byKey[key])
+		group.Output, group.Stderr = output.GetAllStreams(ids...)
 		if count > 0 {
 			total.Iterations /= int64(count)
 			total.NsPerOp /= float64(count)
 			total.MBPerSec /= float64(count)
 			total.BytesPerOp /= int64(count)
 			total.AllocsPerOp /= int64(count)
+			for unit := range total.Metrics {
+				total.Metrics[unit] /= float64(metricCounts[unit])
+			}
 			SetBenchmarkData(&group, total)
 		}
 		grouped[i] = group
@@ -342,9 +513,24 @@ type packageBuilder struct {
 	generateID func() int
 	output     *collector.Output
 
-	tests     map[int]gtr.Test
-	parentIDs map[int]struct{} // set of test id's that contain subtests
-	coverage  float64          // coverage percentage
+	tests      map[int]gtr.Test
+	parentIDs  map[int]struct{} // set of test id's that contain subtests
+	coverage   float64          // coverage percentage
+	covered    bool             // whether the coverage percentage was reported
+	properties []gtr.Property   // package properties, e.g. from benchmark headers
+	redactions int              // number of secrets redacted from the output
+	fuzz       *Fuzz            // fuzzing progress of the currently running fuzz test
+	panics     []panicOutput    // output printed after a panic or timeout
+	races      []Race           // data races not yet attributed to a test
+	lastFailed int              // id of the most recently ended failed test
+}
+
+// panicOutput contains the output printed after a test binary panicked or
+// timed out.
+type panicOutput struct {
+	id      int                   // id of the collected output
+	failure gtr.Failure           // failure details for the affected tests
+	tests   map[int]time.Duration // affected tests and their elapsed time
 }
 
 // newPackageBuilder creates a new packageBuilder. New tests will be assigned
@@ -362,18 +548,21 @@ func newPackageBuilder(generateID func() int, output *collector.Output) *package
 // IsEmpty returns true if this package builder does not have any tests and has
 // not collected any global output.
 func (b packageBuilder) IsEmpty() bool {
-	return len(b.tests) == 0 && !b.output.Contains(0)
+	return len(b.tests) == 0 && !b.output.Contains(0) && len(b.properties) == 0 && len(b.panics) == 0
 }
 
 // CreateTest adds a test with the given name to the package, marks it as
 // active and returns its generated id.
 func (b *packageBuilder) CreateTest(name string) int {
-	if parentID, ok := b.findTestParentID(name); ok {
+	parentID, ok := b.findTestParentID(name)
+	if ok {
 		b.parentIDs[parentID] = struct{}{}
 	}
 	id := b.generateID()
 	b.output.SetActiveID(id)
-	b.tests[id] = gtr.NewTest(id, name)
+	test := gtr.NewTest(id, name)
+	test.ParentID = parentID
+	b.tests[id] = test
 	return id
 }
 
@@ -406,30 +595,55 @@ func (b *packageBuilder) EndTest(name, result string, duration time.Duration, le
 	}
 
 	t := b.tests[id]
+	if t.Kind == gtr.KindFuzz && t.Result != gtr.Unknown && level > t.Level {
+		// When the fuzzing engine finds a failure, the result of the fuzz
+		// test is reported again at a deeper indentation level, followed by
+		// the output of the failing input. Keep the original result, but
+		// collect the output that follows.
+		b.output.SetActiveID(id)
+		return
+	}
+
 	t.Result = parseResult(result)
 	t.Duration = duration
 	t.Level = level
+	if b.fuzz != nil && strings.HasPrefix(name, "Fuzz") {
+		SetFuzzData(&t, *b.fuzz)
+		b.fuzz = nil
+	}
 	b.tests[id] = t
+	if t.Result == gtr.Fail {
+		b.lastFailed = id
+	}
 	b.output.SetActiveID(0)
 }
 
 // End resets the active test.
 func (b *packageBuilder) End() {
 	b.output.SetActiveID(0)
+	b.lastFailed = 0
 }
 
 // BenchmarkResult updates an existing or adds a new test with the given
 // results and marks it as active. If an existing test with this name exists
 // but without result, then that one is updated. Otherwise a new one is added
 // to the report.
-func (b *packageBuilder) BenchmarkResult(name string, iterations int64, nsPerOp, mbPerSec float64, bytesPerOp, allocsPerOp int64) {
+func (b *packageBuilder) BenchmarkResult(name string, cpu int, iterations int64, nsPerOp, mbPerSec float64, bytesPerOp, allocsPerOp int64, metrics map[string]float64) {
 	id, ok := b.findTest(name)
 	if !ok || b.tests[id].Result != gtr.Unknown {
 		id = b.CreateTest(name)
 	}
 	b.output.SetActiveID(id)
 
-	benchmark := Benchmark{iterations, nsPerOp, mbPerSec, bytesPerOp, allocsPerOp}
+	benchmark := Benchmark{
+		CPU:         cpu,
+		Iterations:  iterations,
+		NsPerOp:     nsPerOp,
+		MBPerSec:    mbPerSec,
+		BytesPerOp:  bytesPerOp,
+		AllocsPerOp: allocsPerOp,
+		Metrics:     metrics,
+	}
 	test := gtr.NewTest(id, name)
 	test.Result = gtr.Pass
 	test.Duration = benchmark.ApproximateDuration()
@@ -437,14 +651,251 @@ func (b *packageBuilder) BenchmarkResult(name string, iterations int64, nsPerOp,
 	b.tests[id] = test
 }
 
+// EndBenchmark finds the benchmark with the given name and sets the result
+// and the GOMAXPROCS value it ran with. If no benchmark exists with this name,
+// a new one is created. The benchmark is then marked as no longer active.
+func (b *packageBuilder) EndBenchmark(name, result string, cpu int) {
+	b.EndTest(name, result, 0, 0)
+	if cpu > 0 {
+		id, _ := b.findTest(name)
+		t := b.tests[id]
+		bench, _ := GetBenchmarkData(t)
+		bench.CPU = cpu
+		SetBenchmarkData(&t, bench)
+		b.tests[id] = t
+	}
+}
+
+// BenchmarkHeader records the information printed by go test before running
+// benchmarks. The goos, goarch and cpu values are stored as package
+// properties. Since go test only prints them before the first benchmark, a
+// header that appears while a test is active or after a benchmark result is
+// added to the output instead.
+func (b *packageBuilder) BenchmarkHeader(key, value string, stderr bool) {
+	if b.output.ActiveID() != globalID || b.hasBenchmarkResults() {
+		b.Output(key+": "+value, stderr)
+		return
+	}
+	b.properties = append(b.properties, gtr.Property{Name: key, Value: value})
+}
+
+// hasBenchmarkResults returns true if any benchmark results were added to
+// this package.
+func (b *packageBuilder) hasBenchmarkResults() bool {
+	for _, t := range b.tests {
+		if _, ok := GetBenchmarkData(t); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// DataRace adds the data race to the active test. If no test is active, the
+// race is kept until a test reports that a race was detected during its
+// execution.
+func (b *packageBuilder) DataRace(race Race) {
+	if id := b.output.ActiveID(); id != globalID {
+		if _, ok := b.tests[id]; ok {
+			b.addRaces(id, race)
+			return
+		}
+	}
+	b.races = append(b.races, race)
+}
+
+// RaceDetected adds any unattributed data races to the active test. Without
+// the -v flag, the race is reported after the test has ended, in which case
+// the races are added to the most recently ended failed test, or to the test
+// found in the stack of the race.
+func (b *packageBuilder) RaceDetected() {
+	if len(b.races) == 0 {
+		return
+	}
+	id := b.output.ActiveID()
+	if _, ok := b.tests[id]; !ok {
+		id = b.lastFailed
+	}
+	if _, ok := b.tests[id]; !ok {
+		var trace []string
+		for _, race := range b.races {
+			trace = append(trace, race.functions()...)
+		}
+		if id, ok = b.findPanickingTest(trace); !ok {
+			return
+		}
+	}
+	b.addRaces(id, b.races...)
+	b.races = nil
+}
+
+func (b *packageBuilder) addRaces(id int, races ...Race) {
+	t := b.tests[id]
+	existing, _ := GetRaceData(t)
+	SetRaceData(&t, append(existing, races...))
+	b.tests[id] = t
+}
+
+// ExampleOutput marks the most recently created failed example as active, so
+// that the got and want output that follows is added to it.
+func (b *packageBuilder) ExampleOutput() {
+	if b.output.ActiveID() != globalID {
+		return
+	}
+	var maxid int
+	for id, t := range b.tests {
+		if t.Kind == gtr.KindExample && t.Result == gtr.Fail && id > maxid {
+			maxid = id
+		}
+	}
+	if maxid > 0 {
+		b.output.SetActiveID(maxid)
+	}
+}
+
+// Panic marks the start of the output printed when the test binary panicked.
+// All output until the end of the package will be collected separately, so it
+// can later be added to the test that caused the panic.
+func (b *packageBuilder) Panic(message string) {
+	id := b.generateID()
+	b.panics = append(b.panics, panicOutput{
+		id:      id,
+		failure: gtr.Failure{Type: "panic", Message: message},
+	})
+	b.output.SetActiveID(id)
+}
+
+// Timeout marks the start of the output printed when the test binary timed
+// out. All output until the end of the package will be collected separately,
+// so it can later be added to the tests that were still running.
+func (b *packageBuilder) Timeout(message string) {
+	id := b.generateID()
+	b.panics = append(b.panics, panicOutput{
+		id:      id,
+		failure: gtr.Failure{Type: "timeout", Message: message},
+		tests:   make(map[int]time.Duration),
+	})
+	b.output.SetActiveID(id)
+}
+
+// TimeoutTest records that the test with the given name was still running
+// after the given elapsed time when the test binary timed out. If no test
+// exists with this name, a new test is created.
+func (b *packageBuilder) TimeoutTest(name string, elapsed time.Duration) {
+	if len(b.panics) == 0 {
+		return
+	}
+	p := b.panics[len(b.panics)-1]
+	id, ok := b.findTest(name)
+	if !ok {
+		id = b.CreateTest(name)
+		b.output.SetActiveID(p.id)
+	}
+	p.tests[id] = elapsed
+}
+
+// attachPanics marks the tests affected by a panic or timeout as failed. It
+// returns a map containing, for each affected test id, the ids of the panic
+// output that belong to that test. Output of panics that cannot be attributed
+// to any tests is merged into the global output.
+func (b *packageBuilder) attachPanics() map[int][]int {
+	attached := make(map[int][]int)
+	for _, p := range b.panics {
+		if p.tests == nil {
+			if id, ok := b.findPanickingTest(b.output.Get(p.id)); ok {
+				p.tests = map[int]time.Duration{id: b.tests[id].Duration}
+			}
+		}
+		if len(p.tests) == 0 {
+			b.output.Merge(p.id, globalID)
+			continue
+		}
+		for id, elapsed := range p.tests {
+			t := b.tests[id]
+			t.Result = gtr.Fail
+			t.Duration = elapsed
+			t.Failure = p.failure
+			b.tests[id] = t
+			attached[id] = append(attached[id], p.id)
+		}
+	}
+	b.panics = nil
+	return attached
+}
+
+// findPanickingTest returns the id of the test whose function is found in the
+// given goroutine stack trace. If the function belongs to more than one test,
+// e.g. when it has subtests, the most recently created failed test is
+// preferred.
+func (b *packageBuilder) findPanickingTest(trace []string) (int, bool) {
+	for _, line := range trace {
+		matches := regexStackFrame.FindStringSubmatch(line)
+		if len(matches) != 2 || matches[1] == "TestMain" {
+			continue
+		}
+
+		var found, failed int
+		for id, t := range b.tests {
+			if t.Name != matches[1] && !strings.HasPrefix(t.Name, matches[1]+"/") {
+				continue
+			}
+			if id > found {
+				found = id
+			}
+			if (t.Result == gtr.Fail || t.Result == gtr.Unknown) && id > failed {
+				failed = id
+			}
+		}
+		if failed > 0 {
+			return failed, true
+		} else if found > 0 {
+			return found, true
+		}
+	}
+	return 0, false
+}
+
+// FuzzProgress records the latest fuzzing statistics. They are added to the
+// fuzz test once its result has been reported.
+func (b *packageBuilder) FuzzProgress(elapsed time.Duration, execs, newInteresting, totalInteresting int64) {
+	b.fuzz = &Fuzz{
+		Elapsed:          elapsed,
+		Execs:            execs,
+		NewInteresting:   newInteresting,
+		TotalInteresting: totalInteresting,
+	}
+}
+
+// FuzzFailingInput records the path of the corpus file that caused the fuzz
+// test with the given name to fail, and marks the fuzz test as active.
+func (b *packageBuilder) FuzzFailingInput(name, path string) {
+	id, ok := b.findTest(name)
+	if !ok {
+		return
+	}
+
+	t := b.tests[id]
+	fuzz, _ := GetFuzzData(t)
+	fuzz.FailingInput = path
+	SetFuzzData(&t, fuzz)
+	t.Failure.Message = "Failing input written to " + path
+	b.tests[id] = t
+	b.output.SetActiveID(id)
+}
+
 // Coverage sets the code coverage percentage.
 func (b *packageBuilder) Coverage(pct float64, packages []string) {
 	b.coverage = pct
+	b.covered = true
 }
 
-// Output appends data to the output of this package.
-func (b *packageBuilder) Output(data string) {
-	b.output.Append(data)
+// Output appends data to the output of this package. If stderr is true, data
+// was written to stderr.
+func (b *packageBuilder) Output(data string, stderr bool) {
+	if stderr {
+		b.output.AppendStderr(data)
+	} else {
+		b.output.Append(data)
+	}
 }
 
 // findTest returns the id of the most recently created test with the given
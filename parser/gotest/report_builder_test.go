@@ -77,7 +77,8 @@ func TestReport(t *testing.T) {
 						Output: []string{
 							"\tfile_test.go:10: error",
 						},
-						Data: map[string]interface{}{},
+						Data:    map[string]interface{}{},
+						Failure: gtr.Failure{Message: "error", File: "file_test.go", Line: 10},
 					},
 				},
 			},
@@ -89,12 +90,14 @@ func TestReport(t *testing.T) {
 					{
 						ID:     4,
 						Name:   "BenchmarkOne",
+						Kind:   gtr.KindBenchmark,
 						Result: gtr.Pass,
 						Data:   map[string]interface{}{key: Benchmark{NsPerOp: 100}},
 					},
 					{
 						ID:     5,
 						Name:   "BenchmarkTwo",
+						Kind:   gtr.KindBenchmark,
 						Result: gtr.Fail,
 						Data:   map[string]interface{}{},
 					},
@@ -153,6 +156,7 @@ func TestBuildReportMultiplePackages(t *testing.T) {
 						Result:   gtr.Fail,
 						Output:   []string{"\tfile_test.go:10: error"},
 						Data:     make(map[string]interface{}),
+						Failure:  gtr.Failure{Message: "error", File: "file_test.go", Line: 10},
 					},
 				},
 			},
@@ -185,6 +189,82 @@ func TestBuildReportMultiplePackages(t *testing.T) {
 	}
 }
 
+func TestBuildReportLeftoverOrder(t *testing.T) {
+	events := []Event{
+		{Package: "package/name3", Type: "run_test", Name: "TestThree"},
+		{Package: "package/name1", Type: "run_test", Name: "TestOne"},
+		{Package: "package/name2", Type: "run_test", Name: "TestTwo"},
+		{Type: "build_output", Name: "package/failing2"},
+		{Type: "output", Data: "error 2"},
+		{Type: "build_output", Name: "package/failing1"},
+		{Type: "output", Data: "error 1"},
+	}
+	want := []string{"TestOne", "TestTwo", "TestThree", "package/failing2", "package/failing1"}
+
+	for i := 0; i < 10; i++ {
+		rb := newReportBuilder()
+		for _, ev := range events {
+			rb.ProcessEvent(ev)
+		}
+
+		var got []string
+		for _, pkg := range rb.Build().Packages {
+			if len(pkg.Tests) > 0 {
+				got = append(got, pkg.Tests[0].Name)
+			} else {
+				got = append(got, pkg.BuildError.Name)
+			}
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Fatalf("Incorrect package order, diff (-want, +got):\n%v", diff)
+		}
+	}
+}
+
+func TestBuildReportStderr(t *testing.T) {
+	events := []Event{
+		{Type: "output", Data: "setup", Stderr: true},
+		{Type: "run_test", Name: "TestOne"},
+		{Type: "output", Data: "\tHello"},
+		{Type: "output", Data: "log message", Stderr: true},
+		{Type: "end_test", Name: "TestOne", Result: "PASS", Duration: 1 * time.Millisecond},
+		{Type: "status", Result: "PASS"},
+		{Type: "summary", Result: "ok", Name: "package/name", Duration: 1 * time.Millisecond},
+	}
+
+	want := gtr.Report{
+		Packages: []gtr.Package{
+			{
+				Name:      "package/name",
+				Duration:  1 * time.Millisecond,
+				Timestamp: testTimestamp,
+				Stderr:    []string{"setup"},
+				Tests: []gtr.Test{
+					{
+						ID:       1,
+						Name:     "TestOne",
+						Duration: 1 * time.Millisecond,
+						Result:   gtr.Pass,
+						Output:   []string{"\tHello"},
+						Stderr:   []string{"log message"},
+						Data:     make(map[string]interface{}),
+					},
+				},
+			},
+		},
+	}
+
+	rb := newReportBuilder()
+	rb.timestampFunc = testTimestampFunc
+	for _, ev := range events {
+		rb.ProcessEvent(ev)
+	}
+	got := rb.Build()
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Incorrect report created, diff (-want, +got):\n%v", diff)
+	}
+}
+
 func TestSubtestModes(t *testing.T) {
 	events := []Event{
 		{Type: "run_test", Name: "TestParent"},
@@ -227,6 +307,7 @@ func TestSubtestModes(t *testing.T) {
 							},
 							{
 								ID:       2,
+								ParentID: 1,
 								Name:     "TestParent/Subtest#1",
 								Duration: 2 * time.Millisecond,
 								Result:   gtr.Fail,
@@ -235,6 +316,7 @@ func TestSubtestModes(t *testing.T) {
 							},
 							{
 								ID:       3,
+								ParentID: 1,
 								Name:     "TestParent/Subtest#2",
 								Duration: 3 * time.Millisecond,
 								Result:   gtr.Pass,
@@ -259,6 +341,7 @@ func TestSubtestModes(t *testing.T) {
 						Tests: []gtr.Test{
 							{
 								ID:       2,
+								ParentID: 1,
 								Name:     "TestParent/Subtest#1",
 								Duration: 2 * time.Millisecond,
 								Result:   gtr.Fail,
@@ -267,6 +350,7 @@ func TestSubtestModes(t *testing.T) {
 							},
 							{
 								ID:       3,
+								ParentID: 1,
 								Name:     "TestParent/Subtest#2",
 								Duration: 3 * time.Millisecond,
 								Result:   gtr.Pass,
@@ -311,31 +395,54 @@ func TestGroupBenchmarksByName(t *testing.T) {
 		{"nil", nil, nil},
 		{
 			"one failing benchmark",
-			[]gtr.Test{{ID: 1, Name: "BenchmarkFailed", Result: gtr.Fail, Data: map[string]interface{}{}}},
-			[]gtr.Test{{ID: 1, Name: "BenchmarkFailed", Result: gtr.Fail, Output: []string{"output-1"}, Data: map[string]interface{}{}}},
+			[]gtr.Test{{ID: 1, Name: "BenchmarkFailed", Kind: gtr.KindBenchmark, Result: gtr.Fail, Data: map[string]interface{}{}}},
+			[]gtr.Test{{ID: 1, Name: "BenchmarkFailed", Kind: gtr.KindBenchmark, Result: gtr.Fail, Output: []string{"output-1"}, Data: map[string]interface{}{}}},
 		},
 		{
 			"four passing benchmarks",
 			[]gtr.Test{
-				{ID: 1, Name: "BenchmarkOne", Result: gtr.Pass, Data: map[string]interface{}{key: Benchmark{NsPerOp: 10, MBPerSec: 400, BytesPerOp: 1, AllocsPerOp: 2}}},
-				{ID: 2, Name: "BenchmarkOne", Result: gtr.Pass, Data: map[string]interface{}{key: Benchmark{NsPerOp: 20, MBPerSec: 300, BytesPerOp: 1, AllocsPerOp: 4}}},
-				{ID: 3, Name: "BenchmarkOne", Result: gtr.Pass, Data: map[string]interface{}{key: Benchmark{NsPerOp: 30, MBPerSec: 200, BytesPerOp: 1, AllocsPerOp: 8}}},
-				{ID: 4, Name: "BenchmarkOne", Result: gtr.Pass, Data: map[string]interface{}{key: Benchmark{NsPerOp: 40, MBPerSec: 100, BytesPerOp: 5, AllocsPerOp: 2}}},
+				{ID: 1, Name: "BenchmarkOne", Kind: gtr.KindBenchmark, Result: gtr.Pass, Data: map[string]interface{}{key: Benchmark{NsPerOp: 10, MBPerSec: 400, BytesPerOp: 1, AllocsPerOp: 2}}},
+				{ID: 2, Name: "BenchmarkOne", Kind: gtr.KindBenchmark, Result: gtr.Pass, Data: map[string]interface{}{key: Benchmark{NsPerOp: 20, MBPerSec: 300, BytesPerOp: 1, AllocsPerOp: 4}}},
+				{ID: 3, Name: "BenchmarkOne", Kind: gtr.KindBenchmark, Result: gtr.Pass, Data: map[string]interface{}{key: Benchmark{NsPerOp: 30, MBPerSec: 200, BytesPerOp: 1, AllocsPerOp: 8}}},
+				{ID: 4, Name: "BenchmarkOne", Kind: gtr.KindBenchmark, Result: gtr.Pass, Data: map[string]interface{}{key: Benchmark{NsPerOp: 40, MBPerSec: 100, BytesPerOp: 5, AllocsPerOp: 2}}},
+			},
+			[]gtr.Test{
+				{ID: 1, Name: "BenchmarkOne", Kind: gtr.KindBenchmark, Result: gtr.Pass, Output: []string{"output-1", "output-2", "output-3", "output-4"}, Data: map[string]interface{}{key: Benchmark{NsPerOp: 25, MBPerSec: 250, BytesPerOp: 2, AllocsPerOp: 4}}},
+			},
+		},
+		{
+			"different cpus",
+			[]gtr.Test{
+				{ID: 1, Name: "BenchmarkCPU", Kind: gtr.KindBenchmark, Result: gtr.Pass, Data: map[string]interface{}{key: Benchmark{NsPerOp: 10}}},
+				{ID: 2, Name: "BenchmarkCPU", Kind: gtr.KindBenchmark, Result: gtr.Pass, Data: map[string]interface{}{key: Benchmark{CPU: 2, NsPerOp: 20}}},
+				{ID: 3, Name: "BenchmarkCPU", Kind: gtr.KindBenchmark, Result: gtr.Pass, Data: map[string]interface{}{key: Benchmark{NsPerOp: 30}}},
+				{ID: 4, Name: "BenchmarkCPU", Kind: gtr.KindBenchmark, Result: gtr.Pass, Data: map[string]interface{}{key: Benchmark{CPU: 2, NsPerOp: 40}}},
 			},
 			[]gtr.Test{
-				{ID: 1, Name: "BenchmarkOne", Result: gtr.Pass, Output: []string{"output-1", "output-2", "output-3", "output-4"}, Data: map[string]interface{}{key: Benchmark{NsPerOp: 25, MBPerSec: 250, BytesPerOp: 2, AllocsPerOp: 4}}},
+				{ID: 1, Name: "BenchmarkCPU", Kind: gtr.KindBenchmark, Result: gtr.Pass, Output: []string{"output-1", "output-3"}, Data: map[string]interface{}{key: Benchmark{NsPerOp: 20}}},
+				{ID: 2, Name: "BenchmarkCPU-2", Kind: gtr.KindBenchmark, Result: gtr.Pass, Output: []string{"output-2", "output-4"}, Data: map[string]interface{}{key: Benchmark{CPU: 2, NsPerOp: 30}}},
+			},
+		},
+		{
+			"custom metrics",
+			[]gtr.Test{
+				{ID: 1, Name: "BenchmarkCustom", Kind: gtr.KindBenchmark, Result: gtr.Pass, Data: map[string]interface{}{key: Benchmark{NsPerOp: 10, Metrics: map[string]float64{"ns/op": 10, "p99-ns": 20}}}},
+				{ID: 2, Name: "BenchmarkCustom", Kind: gtr.KindBenchmark, Result: gtr.Pass, Data: map[string]interface{}{key: Benchmark{NsPerOp: 30, Metrics: map[string]float64{"ns/op": 30, "p99-ns": 40, "items/op": 3}}}},
+			},
+			[]gtr.Test{
+				{ID: 1, Name: "BenchmarkCustom", Kind: gtr.KindBenchmark, Result: gtr.Pass, Output: []string{"output-1", "output-2"}, Data: map[string]interface{}{key: Benchmark{NsPerOp: 20, Metrics: map[string]float64{"ns/op": 20, "p99-ns": 30, "items/op": 3}}}},
 			},
 		},
 		{
 			"four mixed result benchmarks",
 			[]gtr.Test{
-				{ID: 1, Name: "BenchmarkMixed", Result: gtr.Unknown},
-				{ID: 2, Name: "BenchmarkMixed", Result: gtr.Pass, Data: map[string]interface{}{key: Benchmark{NsPerOp: 10, MBPerSec: 400, BytesPerOp: 1, AllocsPerOp: 2}}},
-				{ID: 3, Name: "BenchmarkMixed", Result: gtr.Pass, Data: map[string]interface{}{key: Benchmark{NsPerOp: 40, MBPerSec: 100, BytesPerOp: 3, AllocsPerOp: 4}}},
-				{ID: 4, Name: "BenchmarkMixed", Result: gtr.Fail},
+				{ID: 1, Name: "BenchmarkMixed", Kind: gtr.KindBenchmark, Result: gtr.Unknown},
+				{ID: 2, Name: "BenchmarkMixed", Kind: gtr.KindBenchmark, Result: gtr.Pass, Data: map[string]interface{}{key: Benchmark{NsPerOp: 10, MBPerSec: 400, BytesPerOp: 1, AllocsPerOp: 2}}},
+				{ID: 3, Name: "BenchmarkMixed", Kind: gtr.KindBenchmark, Result: gtr.Pass, Data: map[string]interface{}{key: Benchmark{NsPerOp: 40, MBPerSec: 100, BytesPerOp: 3, AllocsPerOp: 4}}},
+				{ID: 4, Name: "BenchmarkMixed", Kind: gtr.KindBenchmark, Result: gtr.Fail},
 			},
 			[]gtr.Test{
-				{ID: 1, Name: "BenchmarkMixed", Result: gtr.Fail, Output: []string{"output-1", "output-2", "output-3", "output-4"}, Data: map[string]interface{}{key: Benchmark{NsPerOp: 25, MBPerSec: 250, BytesPerOp: 2, AllocsPerOp: 3}}},
+				{ID: 1, Name: "BenchmarkMixed", Kind: gtr.KindBenchmark, Result: gtr.Fail, Output: []string{"output-1", "output-2", "output-3", "output-4"}, Data: map[string]interface{}{key: Benchmark{NsPerOp: 25, MBPerSec: 250, BytesPerOp: 2, AllocsPerOp: 3}}},
 			},
 		},
 	}
@@ -392,6 +499,107 @@ func TestReportSpecialCases(t *testing.T) {
 				},
 			},
 		},
+		{
+			"timeout",
+			[]Event{
+				{Type: "run_test", Name: "TestDone"},
+				{Type: "end_test", Name: "TestDone", Result: "PASS"},
+				{Type: "run_test", Name: "TestHang"},
+				{Type: "output", Data: "hanging"},
+				{Type: "timeout", Data: "test timed out after 1s", Duration: 1 * time.Second},
+				{Type: "output", Data: "panic: test timed out after 1s"},
+				{Type: "timeout_test", Name: "TestHang", Duration: 1 * time.Second},
+				{Type: "timeout_test", Name: "TestUnknown", Duration: 500 * time.Millisecond},
+				{Type: "output", Data: "goroutine dump"},
+				{Type: "summary", Result: "FAIL", Name: "package/name"},
+			},
+			gtr.Report{
+				Packages: []gtr.Package{
+					{
+						Name:      "package/name",
+						Timestamp: testTimestamp,
+						Tests: []gtr.Test{
+							{ID: 1, Name: "TestDone", Result: gtr.Pass, Data: map[string]interface{}{}},
+							{
+								ID:       2,
+								Name:     "TestHang",
+								Duration: 1 * time.Second,
+								Result:   gtr.Fail,
+								Output:   []string{"hanging", "panic: test timed out after 1s", "goroutine dump"},
+								Data:     map[string]interface{}{},
+								Failure:  gtr.Failure{Type: "timeout", Message: "test timed out after 1s"},
+							},
+							{
+								ID:       4,
+								Name:     "TestUnknown",
+								Duration: 500 * time.Millisecond,
+								Result:   gtr.Fail,
+								Output:   []string{"panic: test timed out after 1s", "goroutine dump"},
+								Data:     map[string]interface{}{},
+								Failure:  gtr.Failure{Type: "timeout", Message: "test timed out after 1s"},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			"race detected after test ended",
+			[]Event{
+				{Type: "run_test", Name: "TestRace"},
+				{Type: "end_test", Name: "TestRace", Result: "PASS"},
+				{Type: "race", Race: &Race{Accesses: []RaceAccess{{Op: "Write", Goroutine: 8}}}},
+				{Type: "cont_test", Name: "TestRace"},
+				{Type: "race_detected"},
+				{Type: "end_test", Name: "TestRace", Result: "FAIL"},
+				{Type: "summary", Result: "FAIL", Name: "package/name"},
+			},
+			gtr.Report{
+				Packages: []gtr.Package{
+					{
+						Name:      "package/name",
+						Timestamp: testTimestamp,
+						Tests: []gtr.Test{
+							{
+								ID:     1,
+								Name:   "TestRace",
+								Result: gtr.Fail,
+								Data: map[string]interface{}{
+									raceKey: []Race{{Accesses: []RaceAccess{{Op: "Write", Goroutine: 8}}}},
+								},
+								Failure: gtr.Failure{Type: "race", Message: "Data race: write by goroutine 8"},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			"test result reported again at deeper level",
+			[]Event{
+				{Type: "run_test", Name: "TestA"},
+				{Type: "end_test", Name: "TestA", Result: "PASS"},
+				{Type: "end_test", Name: "TestA", Result: "FAIL", Indent: 1},
+				{Type: "summary", Result: "FAIL", Name: "package/name"},
+			},
+			gtr.Report{
+				Packages: []gtr.Package{
+					{
+						Name:      "package/name",
+						Timestamp: testTimestamp,
+						Tests: []gtr.Test{
+							{
+								ID:     1,
+								Name:   "TestA",
+								Result: gtr.Fail,
+								Level:  1,
+								Data:   map[string]interface{}{},
+							},
+						},
+					},
+				},
+			},
+		},
 		{
 			"build error in package with _test suffix",
 			[]Event{
@@ -428,3 +636,27 @@ func TestReportSpecialCases(t *testing.T) {
 		})
 	}
 }
+
+func TestFindFailureLocation(t *testing.T) {
+	output := []string{
+		"    fail_test.go:6: Error message",
+		"    fail_test.go:7: Longer",
+		"        error",
+		"        message.",
+	}
+	tests := []struct {
+		output []string
+		want   gtr.Failure
+	}{
+		{output, gtr.Failure{Message: "Error message", File: "fail_test.go", Line: 6}},
+		{output[1:], gtr.Failure{Message: "Longer\nerror\nmessage.", File: "fail_test.go", Line: 7}},
+		{[]string{"no location"}, gtr.Failure{}},
+	}
+
+	for _, test := range tests {
+		got := findFailureLocation(test.output)
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("findFailureLocation(%q) incorrect, diff (-want, +got):\n%s\n", test.output, diff)
+		}
+	}
+}
@@ -12,15 +12,19 @@ import (
 	"time"
 
 	"github.com/jstemmer/go-junit-report/v2/internal/gojunitreport"
+	"github.com/jstemmer/go-junit-report/v2/junit"
 )
 
 var verbose bool
 
 var configs = map[string]gojunitreport.Config{
-	"005-no-xml-header.txt": {SkipXMLHeader: true},
-	"006-mixed.txt":         {SkipXMLHeader: true},
-	"007-compiled_test.txt": {PackageName: "test/package"},
-	"039-no-properties.txt": {Properties: make(map[string]string)},
+	"005-no-xml-header.txt":   {SkipXMLHeader: true},
+	"006-mixed.txt":           {SkipXMLHeader: true},
+	"007-compiled_test.txt":   {PackageName: "test/package"},
+	"039-no-properties.txt":   {Properties: make(map[string]string)},
+	"045-example.txt":         {GroupByKind: true},
+	"046-attempts.txt":        {GroupAttempts: true},
+	"047-subtests-nested.txt": {SubtestFormat: junit.NestedSuites},
 }
 
 func main() {